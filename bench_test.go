@@ -0,0 +1,133 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type benchConfig struct {
+	Host    string            `env:"HOST"`
+	Port    int               `env:"PORT"`
+	Debug   bool              `env:"DEBUG"`
+	Timeout time.Duration     `env:"TIMEOUT"`
+	Tags    []string          `env:"TAGS"`
+	Limits  map[string]int    `env:"LIMIT_"`
+	Labels  map[string]string `env:"LABEL_"`
+	DB      struct {
+		User     string `env:"USER"`
+		Password string `env:"PASSWORD"`
+	} `env:"DB_"`
+}
+
+// setBenchEnv sets the variables of benchConfig among many unrelated ones.
+func setBenchEnv() {
+	os.Clearenv()
+	for i := 0; i < 200; i++ {
+		os.Setenv(fmt.Sprintf("OTHER_%d", i), "x")
+	}
+	os.Setenv("APP_HOST", "localhost")
+	os.Setenv("APP_PORT", "8080")
+	os.Setenv("APP_DEBUG", "true")
+	os.Setenv("APP_TIMEOUT", "5s")
+	os.Setenv("APP_TAGS", "a,b,c")
+	os.Setenv("APP_LIMIT_users", "10")
+	os.Setenv("APP_LIMIT_orders", "20")
+	os.Setenv("APP_LABEL_team", "core")
+	os.Setenv("APP_DB_USER", "joe")
+	os.Setenv("APP_DB_PASSWORD", "hunter2")
+}
+
+func BenchmarkLoad(b *testing.B) {
+	setBenchEnv()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var c benchConfig
+		if err := Load(&c, "APP_"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkLoaderLoad(b *testing.B) {
+	setBenchEnv()
+	l := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var c benchConfig
+		if err := l.Load(&c, "APP_"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkDescribe(b *testing.B) {
+	l := New()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := l.Describe((*benchConfig)(nil), "APP_"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestPlanCache(t *testing.T) {
+	a := assert.New(t)
+
+	type point struct {
+		X int `env:"X"`
+	}
+	type cfg struct {
+		P point `env:"P_"`
+	}
+
+	os.Clearenv()
+	os.Setenv("P_X", "1")
+	os.Setenv("P_", "22")
+
+	l := New()
+	var c cfg
+	a.NoError(l.Load(&c, ""))
+	a.Equal(1, c.P.X)
+	a.Len(l.plan(reflect.TypeOf(c), ""), 1)
+
+	// A parser of the nested struct makes it a single variable.
+	l.AddParser(reflect.TypeOf(point{}), func(s string) (interface{}, error) {
+		return point{len(s)}, nil
+	})
+	c = cfg{}
+	a.NoError(l.Load(&c, ""))
+	a.Equal(2, c.P.X)
+
+	// The plans of the other loaders are not affected.
+	c = cfg{}
+	a.NoError(New().Load(&c, ""))
+	a.Equal(1, c.P.X)
+}
+
+func TestSnapshot(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("A_1", "1")
+	os.Setenv("A_2", "2")
+	os.Setenv("AB", "3")
+	os.Setenv("B", "4")
+
+	s := takeSnapshot(Layered(Map("test", map[string]string{"A_3": "5", "B": "6"}), OSEnv()))
+	a.Equal([]string{"AB", "A_1", "A_2", "A_3", "B"}, s.Names())
+	a.Equal([]string{"A_1", "A_2", "A_3"}, s.prefixed("A_"))
+	a.Empty(s.prefixed("C"))
+	v, ok := s.Lookup("B")
+	a.True(ok)
+	a.Equal(Var{"6", Origin{Source: "test"}}, v)
+
+	// The snapshot doesn't see the later changes.
+	os.Setenv("A_4", "7")
+	a.Equal([]string{"A_1", "A_2", "A_3"}, s.prefixed("A_"))
+	a.Equal([]string{"AB", "A_1", "A_2", "A_3", "B"}, s.Names())
+}
@@ -0,0 +1,63 @@
+package env
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// defaultByteEncoding is used for byte slices and arrays which don't have the
+// encoding tag option.
+const defaultByteEncoding = "base64"
+
+// byteEncodings maps the values of the encoding tag option to the functions
+// decoding the variable value.
+var byteEncodings = map[string]func(s string) ([]byte, error){
+	"base64":    decodeBase64(base64.StdEncoding),
+	"base64url": decodeBase64(base64.URLEncoding),
+	"hex":       hex.DecodeString,
+	"raw":       func(s string) ([]byte, error) { return []byte(s), nil },
+}
+
+var byteType = reflect.TypeOf(byte(0))
+
+// decodeBase64 returns a function decoding s using enc. The padding is
+// optional as it's often stripped when the value is copied around.
+func decodeBase64(enc *base64.Encoding) func(s string) ([]byte, error) {
+	raw := enc.WithPadding(base64.NoPadding)
+	return func(s string) ([]byte, error) {
+		return raw.DecodeString(strings.TrimRight(s, "="))
+	}
+}
+
+// isBytes reports whether rt is a byte slice or a byte array. Such types are
+// decoded as a whole instead of being parsed as a comma-separated list.
+func isBytes(rt reflect.Type) bool {
+	k := rt.Kind()
+	return (k == reflect.Slice || k == reflect.Array) && rt.Elem() == byteType
+}
+
+// parseAndSetBytes decodes s using the encoding from opts. When rv is an
+// array, the decoded value must fit it exactly.
+func parseAndSetBytes(s string, rv reflect.Value, opts tagOptions) error {
+	enc := opts.encoding
+	if enc == "" {
+		enc = defaultByteEncoding
+	}
+	b, err := byteEncodings[enc](s)
+	if err != nil {
+		return fmt.Errorf("invalid %s: %w", enc, err)
+	}
+	rt := rv.Type()
+	if rt.Kind() == reflect.Array {
+		if len(b) != rt.Len() {
+			return fmt.Errorf("expected %d bytes, got %d", rt.Len(), len(b))
+		}
+		reflect.Copy(rv, reflect.ValueOf(b))
+		return nil
+	}
+	rv.Set(reflect.ValueOf(b).Convert(rt))
+	return nil
+}
@@ -0,0 +1,80 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytes(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Default   []byte   `env:"DEFAULT"`
+		Base64    []byte   `env:"BASE64,encoding=base64"`
+		Base64URL []byte   `env:"BASE64URL,encoding=base64url"`
+		Hex       []byte   `env:"HEX,encoding=hex"`
+		Raw       []byte   `env:"RAW,encoding=raw"`
+		Array     [4]byte  `env:"ARRAY,encoding=hex"`
+		Ptr       *[]byte  `env:"PTR,encoding=raw"`
+		Slice     [][]byte `env:"SLICE,encoding=hex"`
+	}
+
+	os.Clearenv()
+	os.Setenv("DEFAULT", "AP8=")
+	os.Setenv("BASE64", "AP8")
+	os.Setenv("BASE64URL", "AP_-")
+	os.Setenv("HEX", "00ff")
+	os.Setenv("RAW", "foo,bar")
+	os.Setenv("ARRAY", "deadbeef")
+	os.Setenv("PTR", "x")
+	os.Setenv("SLICE", "00,ff")
+
+	var c cfg
+	err := Load(&c, "")
+	a.NoError(err)
+	a.Equal([]byte{0x00, 0xff}, c.Default)
+	a.Equal([]byte{0x00, 0xff}, c.Base64)
+	a.Equal([]byte{0x00, 0xff, 0xfe}, c.Base64URL)
+	a.Equal([]byte{0x00, 0xff}, c.Hex)
+	a.Equal([]byte("foo,bar"), c.Raw)
+	a.Equal([4]byte{0xde, 0xad, 0xbe, 0xef}, c.Array)
+	a.Equal([]byte("x"), *c.Ptr)
+	a.Equal([][]byte{{0x00}, {0xff}}, c.Slice)
+}
+
+func TestBytesBad(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Hex   []byte   `env:"HEX,encoding=hex"`
+		Array [32]byte `env:"ARRAY"`
+	}
+
+	samples := map[string]string{
+		"HEX":   "xyz",
+		"ARRAY": "AP8=",
+	}
+	for k, v := range samples {
+		os.Clearenv()
+		os.Setenv("HEX", "00")
+		os.Setenv("ARRAY", "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA=")
+		os.Setenv(k, v)
+
+		var c cfg
+		a.Error(Load(&c, ""), "loading invalid %q should fail", k)
+	}
+
+	type badTag struct {
+		Bytes []byte `env:"BYTES,encoding=rot13"`
+	}
+	var c badTag
+	a.Error(Load(&c, ""))
+
+	os.Setenv("PORT", "10")
+	var n struct {
+		Port int `env:"PORT,encoding=hex"`
+	}
+	a.EqualError(Load(&n, ""), `env: cannot load environment config: "Port": encoding option used for int, which holds no bytes`)
+}
@@ -0,0 +1,389 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"reflect"
+	"strings"
+)
+
+const envPath = "github.com/Showmax/env"
+
+// generator generates the loaders of the structs of pkg.
+type generator struct {
+	pkg    *types.Package
+	prefix string
+
+	// imports and testImports are the paths of the packages used by the
+	// generated loaders and tests.
+	imports     map[string]bool
+	testImports map[string]bool
+
+	body  strings.Builder
+	tests strings.Builder
+
+	// vars are the variables loaded by the current loader, used by its
+	// test.
+	vars []variable
+
+	// outer are the struct types walked into, to detect the recursive
+	// types.
+	outer []types.Type
+}
+
+// variable is a variable loaded by a generated loader.
+type variable struct {
+	name string
+	kind varKind
+
+	// sample is a valid value of the variable, or of the variables
+	// prefixed by name for maps.
+	sample string
+}
+
+type varKind int
+
+const (
+	scalarVar varKind = iota
+	sliceVar
+	mapVar
+)
+
+func newGenerator(pkg *types.Package, prefix string) *generator {
+	return &generator{
+		pkg:         pkg,
+		prefix:      prefix,
+		imports:     make(map[string]bool),
+		testImports: make(map[string]bool),
+	}
+}
+
+// loader generates the loader of the struct type called name.
+func (g *generator) loader(name string) error {
+	obj, ok := g.pkg.Scope().Lookup(name).(*types.TypeName)
+	if !ok {
+		return fmt.Errorf("type %s not found", name)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok || named.TypeParams() != nil {
+		return fmt.Errorf("%s must be a struct type without type parameters", name)
+	}
+	st, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("%s must be a struct type", name)
+	}
+
+	g.imports[envPath] = true
+	g.vars = nil
+	g.outer = []types.Type{named}
+	var b strings.Builder
+	if err := g.walk(&b, st, g.prefix, "cfg", name); err != nil {
+		return err
+	}
+	fmt.Fprintf(&g.body, "// Load%s loads a new %s from src, or from the environment if src is\n", name, name)
+	fmt.Fprintf(&g.body, "// nil, the same way as env.New(env.Sources(src)).Load with the prefix %q\n", g.prefix)
+	g.body.WriteString("// while no parsers are registered in env.DefaultRegistry.\n")
+	fmt.Fprintf(&g.body, "func Load%s(src env.Source) (%s, error) {\n", name, name)
+	fmt.Fprintf(&g.body, "\tvar cfg %s\n\tg := env.NewGen(src)\n", name)
+	g.body.WriteString(b.String())
+	fmt.Fprintf(&g.body, "\tif err := g.Err(); err != nil {\n\t\treturn %s{}, err\n\t}\n", name)
+	g.body.WriteString("\treturn cfg, nil\n}\n\n")
+	return nil
+}
+
+// walk generates the loading of the fields of st, which is accessed by expr,
+// in the same way as env.Loader.walk. The path is used in the errors.
+func (g *generator) walk(b *strings.Builder, st *types.Struct, prefix, expr, path string) error {
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		fpath := path + "." + f.Name()
+		tag, hasTag := reflect.StructTag(st.Tag(i)).Lookup("env")
+		ft := unalias(f.Type())
+		p, isPtr := ft.(*types.Pointer)
+		if isPtr {
+			ft = p.Elem()
+		}
+		fst, isStruct := ft.Underlying().(*types.Struct)
+		if tag == "-" || !hasTag && !(isStruct && f.Anonymous()) {
+			continue
+		}
+		if !f.Exported() {
+			return fmt.Errorf("%s: cannot write unexported field", fpath)
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if opts != "" {
+			return fmt.Errorf("%s: tag options are not supported: %q", fpath, opts)
+		}
+		name = prefix + name
+		fexpr := expr + "." + f.Name()
+		if isEnvUnmarshaler(f.Type()) {
+			return fmt.Errorf("%s: EnvUnmarshalers are not supported", fpath)
+		}
+		if isStruct && !isLeaf(ft) {
+			if isPtr {
+				for _, outer := range g.outer {
+					if types.Identical(outer, ft) {
+						return fmt.Errorf("%s: recursive type %s", fpath, typeString(ft))
+					}
+				}
+				fmt.Fprintf(b, "\t%s = new(%s)\n", fexpr, g.typeName(ft))
+			}
+			g.outer = append(g.outer, ft)
+			err := g.walk(b, fst, name, fexpr, fpath)
+			g.outer = g.outer[:len(g.outer)-1]
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if err := g.field(b, f.Type(), name, fexpr); err != nil {
+			return fmt.Errorf("%s: %w", fpath, err)
+		}
+	}
+	return nil
+}
+
+// field generates the loading of the variable called name to the field of type
+// rt accessed by expr.
+func (g *generator) field(b *strings.Builder, rt types.Type, name, expr string) error {
+	dst := "&" + expr
+	if p, ok := rt.(*types.Pointer); ok {
+		// The pointers are followed by the loader, allocating the
+		// values.
+		rt = p.Elem()
+		if _, ok := rt.(*types.Pointer); ok {
+			return fmt.Errorf("pointers to pointers are not supported")
+		}
+		fmt.Fprintf(b, "\t%s = new(%s)\n", expr, g.typeName(rt))
+		dst = expr
+	}
+	if m, ok := rt.Underlying().(*types.Map); ok {
+		key, keySample, err := g.parser(m.Key())
+		if err != nil {
+			return err
+		}
+		val, valSample, err := g.parser(m.Elem())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "\tenv.GenMap(g, %q, %q, %q, %q, %s, %s, %s)\n",
+			name, typeString(rt), typeString(m.Key()), typeString(m.Elem()), dst, key, val)
+		g.vars = append(g.vars, variable{name + keySample, mapVar, valSample})
+		return nil
+	}
+	if parse, sample, err := g.parser(rt); err == nil {
+		fmt.Fprintf(b, "\tenv.GenVar(g, %q, %q, %s, %s)\n", name, typeString(rt), dst, parse)
+		g.vars = append(g.vars, variable{name, scalarVar, sample})
+		return nil
+	}
+	if sl, ok := rt.Underlying().(*types.Slice); ok && !isBytes(rt) {
+		parse, sample, err := g.parser(sl.Elem())
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "\tenv.GenSlice(g, %q, %q, %s, %s)\n", name, typeString(rt), dst, parse)
+		g.vars = append(g.vars, variable{name, sliceVar, sample + "," + sample})
+		return nil
+	}
+	_, _, err := g.parser(rt)
+	return err
+}
+
+// parser returns the expression of a function parsing a single value of rt,
+// which is either parsed by a default parser of the loader or is
+// a TextUnmarshaler, and a sample value.
+func (g *generator) parser(rt types.Type) (expr, sample string, err error) {
+	rt = unalias(rt)
+	if p, ok := defaultParsers[types.TypeString(rt, nil)]; ok {
+		for _, path := range p.imports {
+			g.imports[path] = true
+		}
+		return p.expr, p.sample, nil
+	}
+	if isTextUnmarshaler(rt) {
+		name := g.typeName(rt)
+		expr := fmt.Sprintf("func(s string) (%s, error) {\n\t\tvar v %s\n\t\terr := v.UnmarshalText([]byte(s))\n\t\treturn v, err\n\t}", name, name)
+		return expr, "", nil
+	}
+	if isBytes(rt) {
+		return "", "", fmt.Errorf("byte slices are not supported")
+	}
+	return "", "", fmt.Errorf("parsing of %s not supported", typeString(rt))
+}
+
+// typeName returns the name of rt in the generated code.
+func (g *generator) typeName(rt types.Type) string {
+	return types.TypeString(rt, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = true
+		return p.Name()
+	})
+}
+
+// typeString returns the name of rt as it's printed by the reflect package.
+func typeString(rt types.Type) string {
+	return types.TypeString(unalias(rt), func(p *types.Package) string {
+		return p.Name()
+	})
+}
+
+// isLeaf tells whether the struct type rt is loaded from a single variable
+// rather than recursed into.
+func isLeaf(rt types.Type) bool {
+	_, ok := defaultParsers[types.TypeString(unalias(rt), nil)]
+	return ok || isTextUnmarshaler(rt)
+}
+
+var textUnmarshaler = types.NewInterfaceType([]*types.Func{
+	types.NewFunc(0, nil, "UnmarshalText", types.NewSignatureType(nil, nil, nil,
+		types.NewTuple(types.NewVar(0, nil, "text", types.NewSlice(types.Typ[types.Byte]))),
+		types.NewTuple(types.NewVar(0, nil, "", types.Universe.Lookup("error").Type())),
+		false)),
+}, nil).Complete()
+
+// isEnvUnmarshaler tells whether rt or a pointer to it has the UnmarshalEnv
+// method of env.EnvUnmarshaler.
+func isEnvUnmarshaler(rt types.Type) bool {
+	obj, _, _ := types.LookupFieldOrMethod(types.NewPointer(rt), true, nil, "UnmarshalEnv")
+	_, ok := obj.(*types.Func)
+	return ok
+}
+
+func isTextUnmarshaler(rt types.Type) bool {
+	return types.Implements(rt, textUnmarshaler) || types.Implements(types.NewPointer(rt), textUnmarshaler)
+}
+
+// isBytes tells whether rt is loaded as binary data by the loader.
+func isBytes(rt types.Type) bool {
+	var elem types.Type
+	switch t := rt.Underlying().(type) {
+	case *types.Slice:
+		elem = t.Elem()
+	case *types.Array:
+		elem = t.Elem()
+	default:
+		return false
+	}
+	b, ok := elem.(*types.Basic)
+	return ok && b.Kind() == types.Byte
+}
+
+// defaultParser is the generated counterpart of a default parser of the
+// loader.
+type defaultParser struct {
+	expr    string
+	imports []string
+	sample  string
+}
+
+// defaultParsers are the default parsers by the full names of their types.
+var defaultParsers = map[string]defaultParser{
+	"bool": {"strconv.ParseBool", []string{"strconv"}, "true"},
+	"io/fs.FileMode": {`func(s string) (os.FileMode, error) {
+		if len(s) > 0 && s[0] != '0' {
+			return 0, errors.New("file mode must be prefixed with 0")
+		}
+		v, err := strconv.ParseUint(s, 8, 32)
+		return os.FileMode(v), err
+	}`, []string{"errors", "os", "strconv"}, "0644"},
+	"float32": {`func(s string) (float32, error) {
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	}`, []string{"strconv"}, "1.5"},
+	"float64":       {"func(s string) (float64, error) { return strconv.ParseFloat(s, 64) }", []string{"strconv"}, "1.5"},
+	"int":           {"strconv.Atoi", []string{"strconv"}, "1"},
+	"uint":          intParser("uint", "strconv.IntSize"),
+	"int8":          intParser("int8", "8"),
+	"uint8":         intParser("uint8", "8"),
+	"int16":         intParser("int16", "16"),
+	"uint16":        intParser("uint16", "16"),
+	"int32":         intParser("int32", "32"),
+	"uint32":        intParser("uint32", "32"),
+	"int64":         {"func(s string) (int64, error) { return strconv.ParseInt(s, 10, 64) }", []string{"strconv"}, "1"},
+	"uint64":        {"func(s string) (uint64, error) { return strconv.ParseUint(s, 10, 64) }", []string{"strconv"}, "1"},
+	"string":        {"func(s string) (string, error) { return s, nil }", nil, "x"},
+	"regexp.Regexp": pointerParser("regexp.Regexp", "regexp.Compile(s)", "regexp", "a+"),
+	"time.Duration": {"time.ParseDuration", []string{"time"}, "1s"},
+	"net/url.URL":   pointerParser("url.URL", "url.Parse(s)", "net/url", "https://example.org"),
+	"text/template.Template": pointerParser("template.Template",
+		`template.New("from_env").Parse(s)`, "text/template", "{{.}}"),
+}
+
+func intParser(typ, bits string) defaultParser {
+	parse := "strconv.ParseInt"
+	if strings.HasPrefix(typ, "u") {
+		parse = "strconv.ParseUint"
+	}
+	expr := fmt.Sprintf("func(s string) (%s, error) {\n\t\tv, err := %s(s, 10, %s)\n\t\treturn %s(v), err\n\t}", typ, parse, bits, typ)
+	return defaultParser{expr, []string{"strconv"}, "1"}
+}
+
+// pointerParser returns a parser of typ using call, which returns a pointer.
+func pointerParser(typ, call, path, sample string) defaultParser {
+	expr := fmt.Sprintf("func(s string) (%s, error) {\n\t\tv, err := %s\n\t\tif err != nil {\n\t\t\treturn %s{}, err\n\t\t}\n\t\treturn *v, nil\n\t}", typ, call, typ)
+	return defaultParser{expr, []string{path}, sample}
+}
+
+// garbage is an invalid value of most of the types.
+const garbage = "!"
+
+// test generates a test of the loader of the type called name, comparing it
+// with the reflective loader on an empty environment, on garbage values and on
+// sample values.
+func (g *generator) test(name string) {
+	for _, path := range []string{envPath, "fmt", "reflect", "testing"} {
+		g.testImports[path] = true
+	}
+
+	fmt.Fprintf(&g.tests, "// TestLoad%sGenerated checks that Load%s agrees with env.Load.\n", name, name)
+	fmt.Fprintf(&g.tests, "func TestLoad%sGenerated(t *testing.T) {\n", name)
+	g.tests.WriteString("\tfor _, vars := range []map[string]string{\n\t\t{},\n")
+	for _, values := range []func(v variable) string{
+		func(v variable) string { return garbage },
+		func(v variable) string { return v.sample },
+	} {
+		g.tests.WriteString("\t\t{\n")
+		for _, v := range g.vars {
+			fmt.Fprintf(&g.tests, "\t\t\t%q: %q,\n", v.name, values(v))
+		}
+		g.tests.WriteString("\t\t},\n")
+	}
+	g.tests.WriteString("\t} {\n")
+	g.tests.WriteString("\t\tsrc := env.Map(\"test\", vars)\n")
+	fmt.Fprintf(&g.tests, "\t\tgot, gotErr := Load%s(src)\n", name)
+	fmt.Fprintf(&g.tests, "\t\tvar want %s\n", name)
+	fmt.Fprintf(&g.tests, "\t\twantErr := env.New(env.Sources(src)).Load(&want, %q)\n", g.prefix)
+	g.tests.WriteString(`		if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) {
+			t.Errorf("%v: got error %v, want %v", vars, gotErr, wantErr)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got %+v, want %+v", vars, got, want)
+		}
+	}
+}
+
+`)
+}
+
+// unalias replaces the aliases in rt, e.g. os.FileMode, by the types they
+// refer to, as they are seen by the reflect package. The aliases are
+// recognized by their Rhs method, so that it builds with older versions of
+// go/types which resolve the aliases themselves.
+func unalias(rt types.Type) types.Type {
+	if a, ok := rt.(interface{ Rhs() types.Type }); ok {
+		return unalias(a.Rhs())
+	}
+	switch t := rt.(type) {
+	case *types.Pointer:
+		return types.NewPointer(unalias(t.Elem()))
+	case *types.Slice:
+		return types.NewSlice(unalias(t.Elem()))
+	case *types.Array:
+		return types.NewArray(unalias(t.Elem()), t.Len())
+	case *types.Map:
+		return types.NewMap(unalias(t.Key()), unalias(t.Elem()))
+	}
+	return rt
+}
@@ -0,0 +1,65 @@
+// Package example is loaded by a loader generated by envgen.
+package example
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"time"
+)
+
+//go:generate go run github.com/Showmax/env/cmd/envgen -type Config -prefix APP_ -test
+
+type Config struct {
+	Host     string            `env:"HOST"`
+	Port     uint16            `env:"PORT"`
+	Debug    bool              `env:"DEBUG"`
+	Ratio    float32           `env:"RATIO"`
+	Workers  *int              `env:"WORKERS"`
+	Tags     []string          `env:"TAGS"`
+	Backoff  []time.Duration   `env:"BACKOFF"`
+	Limits   map[string]int    `env:"LIMIT_"`
+	Upstream url.URL           `env:"UPSTREAM"`
+	Filter   regexp.Regexp     `env:"FILTER"`
+	Bind     net.IP            `env:"BIND"`
+	Mode     os.FileMode       `env:"MODE"`
+	Level    Level             `env:"LEVEL"`
+	Weights  map[Level]float64 `env:"WEIGHT_"`
+	DB       DB                `env:"DB_"`
+	Replica  *DB               `env:"REPLICA_"`
+	Timeouts
+	*Pool
+
+	Ignored  string
+	Excluded string `env:"-"`
+}
+
+type DB struct {
+	User     string `env:"USER"`
+	Password string `env:"PASSWORD"`
+}
+
+type Pool struct {
+	MaxConns int `env:"MAX_CONNS"`
+}
+
+type Timeouts struct {
+	Read  time.Duration `env:"READ_TIMEOUT"`
+	Write time.Duration `env:"WRITE_TIMEOUT"`
+}
+
+// Level is a logging level.
+type Level int
+
+func (l *Level) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "debug":
+		*l = 0
+	case "info":
+		*l = 1
+	default:
+		return os.ErrInvalid
+	}
+	return nil
+}
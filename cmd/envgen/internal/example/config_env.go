@@ -0,0 +1,87 @@
+// Code generated by envgen -type Config -prefix APP_ -test; DO NOT EDIT.
+
+package example
+
+import (
+	"errors"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/Showmax/env"
+)
+
+// LoadConfig loads a new Config from src, or from the environment if src is
+// nil, the same way as env.New(env.Sources(src)).Load with the prefix "APP_"
+// while no parsers are registered in env.DefaultRegistry.
+func LoadConfig(src env.Source) (Config, error) {
+	var cfg Config
+	g := env.NewGen(src)
+	env.GenVar(g, "APP_HOST", "string", &cfg.Host, func(s string) (string, error) { return s, nil })
+	env.GenVar(g, "APP_PORT", "uint16", &cfg.Port, func(s string) (uint16, error) {
+		v, err := strconv.ParseUint(s, 10, 16)
+		return uint16(v), err
+	})
+	env.GenVar(g, "APP_DEBUG", "bool", &cfg.Debug, strconv.ParseBool)
+	env.GenVar(g, "APP_RATIO", "float32", &cfg.Ratio, func(s string) (float32, error) {
+		v, err := strconv.ParseFloat(s, 32)
+		return float32(v), err
+	})
+	cfg.Workers = new(int)
+	env.GenVar(g, "APP_WORKERS", "int", cfg.Workers, strconv.Atoi)
+	env.GenSlice(g, "APP_TAGS", "[]string", &cfg.Tags, func(s string) (string, error) { return s, nil })
+	env.GenSlice(g, "APP_BACKOFF", "[]time.Duration", &cfg.Backoff, time.ParseDuration)
+	env.GenMap(g, "APP_LIMIT_", "map[string]int", "string", "int", &cfg.Limits, func(s string) (string, error) { return s, nil }, strconv.Atoi)
+	env.GenVar(g, "APP_UPSTREAM", "url.URL", &cfg.Upstream, func(s string) (url.URL, error) {
+		v, err := url.Parse(s)
+		if err != nil {
+			return url.URL{}, err
+		}
+		return *v, nil
+	})
+	env.GenVar(g, "APP_FILTER", "regexp.Regexp", &cfg.Filter, func(s string) (regexp.Regexp, error) {
+		v, err := regexp.Compile(s)
+		if err != nil {
+			return regexp.Regexp{}, err
+		}
+		return *v, nil
+	})
+	env.GenVar(g, "APP_BIND", "net.IP", &cfg.Bind, func(s string) (net.IP, error) {
+		var v net.IP
+		err := v.UnmarshalText([]byte(s))
+		return v, err
+	})
+	env.GenVar(g, "APP_MODE", "fs.FileMode", &cfg.Mode, func(s string) (os.FileMode, error) {
+		if len(s) > 0 && s[0] != '0' {
+			return 0, errors.New("file mode must be prefixed with 0")
+		}
+		v, err := strconv.ParseUint(s, 8, 32)
+		return os.FileMode(v), err
+	})
+	env.GenVar(g, "APP_LEVEL", "example.Level", &cfg.Level, func(s string) (Level, error) {
+		var v Level
+		err := v.UnmarshalText([]byte(s))
+		return v, err
+	})
+	env.GenMap(g, "APP_WEIGHT_", "map[example.Level]float64", "example.Level", "float64", &cfg.Weights, func(s string) (Level, error) {
+		var v Level
+		err := v.UnmarshalText([]byte(s))
+		return v, err
+	}, func(s string) (float64, error) { return strconv.ParseFloat(s, 64) })
+	env.GenVar(g, "APP_DB_USER", "string", &cfg.DB.User, func(s string) (string, error) { return s, nil })
+	env.GenVar(g, "APP_DB_PASSWORD", "string", &cfg.DB.Password, func(s string) (string, error) { return s, nil })
+	cfg.Replica = new(DB)
+	env.GenVar(g, "APP_REPLICA_USER", "string", &cfg.Replica.User, func(s string) (string, error) { return s, nil })
+	env.GenVar(g, "APP_REPLICA_PASSWORD", "string", &cfg.Replica.Password, func(s string) (string, error) { return s, nil })
+	env.GenVar(g, "APP_READ_TIMEOUT", "time.Duration", &cfg.Timeouts.Read, time.ParseDuration)
+	env.GenVar(g, "APP_WRITE_TIMEOUT", "time.Duration", &cfg.Timeouts.Write, time.ParseDuration)
+	cfg.Pool = new(Pool)
+	env.GenVar(g, "APP_MAX_CONNS", "int", &cfg.Pool.MaxConns, strconv.Atoi)
+	if err := g.Err(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
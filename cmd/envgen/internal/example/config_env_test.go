@@ -0,0 +1,75 @@
+// Code generated by envgen -type Config -prefix APP_ -test; DO NOT EDIT.
+
+package example
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Showmax/env"
+)
+
+// TestLoadConfigGenerated checks that LoadConfig agrees with env.Load.
+func TestLoadConfigGenerated(t *testing.T) {
+	for _, vars := range []map[string]string{
+		{},
+		{
+			"APP_HOST":             "!",
+			"APP_PORT":             "!",
+			"APP_DEBUG":            "!",
+			"APP_RATIO":            "!",
+			"APP_WORKERS":          "!",
+			"APP_TAGS":             "!",
+			"APP_BACKOFF":          "!",
+			"APP_LIMIT_x":          "!",
+			"APP_UPSTREAM":         "!",
+			"APP_FILTER":           "!",
+			"APP_BIND":             "!",
+			"APP_MODE":             "!",
+			"APP_LEVEL":            "!",
+			"APP_WEIGHT_":          "!",
+			"APP_DB_USER":          "!",
+			"APP_DB_PASSWORD":      "!",
+			"APP_REPLICA_USER":     "!",
+			"APP_REPLICA_PASSWORD": "!",
+			"APP_READ_TIMEOUT":     "!",
+			"APP_WRITE_TIMEOUT":    "!",
+			"APP_MAX_CONNS":        "!",
+		},
+		{
+			"APP_HOST":             "x",
+			"APP_PORT":             "1",
+			"APP_DEBUG":            "true",
+			"APP_RATIO":            "1.5",
+			"APP_WORKERS":          "1",
+			"APP_TAGS":             "x,x",
+			"APP_BACKOFF":          "1s,1s",
+			"APP_LIMIT_x":          "1",
+			"APP_UPSTREAM":         "https://example.org",
+			"APP_FILTER":           "a+",
+			"APP_BIND":             "",
+			"APP_MODE":             "0644",
+			"APP_LEVEL":            "",
+			"APP_WEIGHT_":          "1.5",
+			"APP_DB_USER":          "x",
+			"APP_DB_PASSWORD":      "x",
+			"APP_REPLICA_USER":     "x",
+			"APP_REPLICA_PASSWORD": "x",
+			"APP_READ_TIMEOUT":     "1s",
+			"APP_WRITE_TIMEOUT":    "1s",
+			"APP_MAX_CONNS":        "1",
+		},
+	} {
+		src := env.Map("test", vars)
+		got, gotErr := LoadConfig(src)
+		var want Config
+		wantErr := env.New(env.Sources(src)).Load(&want, "APP_")
+		if fmt.Sprint(gotErr) != fmt.Sprint(wantErr) {
+			t.Errorf("%v: got error %v, want %v", vars, gotErr, wantErr)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%v: got %+v, want %+v", vars, got, want)
+		}
+	}
+}
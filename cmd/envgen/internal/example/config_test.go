@@ -0,0 +1,48 @@
+package example
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Showmax/env"
+)
+
+func TestLoadConfig(t *testing.T) {
+	a := assert.New(t)
+
+	src := env.Map("test", map[string]string{
+		"APP_HOST":             "localhost",
+		"APP_PORT":             "8080",
+		"APP_DEBUG":            "true",
+		"APP_RATIO":            "0.5",
+		"APP_WORKERS":          "4",
+		"APP_TAGS":             `a, "b,c"`,
+		"APP_BACKOFF":          "1s,2s",
+		"APP_LIMIT_users":      "10",
+		"APP_UPSTREAM":         "https://example.org/api",
+		"APP_FILTER":           "^/api/",
+		"APP_BIND":             "127.0.0.1",
+		"APP_MODE":             "0600",
+		"APP_LEVEL":            "info",
+		"APP_WEIGHT_debug":     "0.1",
+		"APP_WEIGHT_info":      "1",
+		"APP_DB_USER":          "joe",
+		"APP_DB_PASSWORD":      "hunter2",
+		"APP_REPLICA_USER":     "bob",
+		"APP_REPLICA_PASSWORD": "s3cr3t",
+		"APP_MAX_CONNS":        "16",
+		"APP_READ_TIMEOUT":     "5s",
+		"APP_WRITE_TIMEOUT":    "10s",
+	})
+	got, err := LoadConfig(src)
+	a.NoError(err)
+	var want Config
+	a.NoError(env.New(env.Sources(src)).Load(&want, "APP_"))
+	a.Equal(want, got)
+	a.Equal(4, *got.Workers)
+	a.Equal([]string{"a", "b,c"}, got.Tags)
+	a.Equal(map[Level]float64{0: 0.1, 1: 1}, got.Weights)
+	a.Equal(&DB{"bob", "s3cr3t"}, got.Replica)
+	a.Equal(16, got.MaxConns)
+}
@@ -0,0 +1,158 @@
+// Command envgen generates loaders of configuration structs which don't use
+// reflection. It's meant to be run by go generate:
+//
+//	//go:generate go run github.com/Showmax/env/cmd/envgen -type Config -prefix APP_
+//
+// For each of the types, it generates a function like
+//
+//	func LoadConfig(src env.Source) (Config, error)
+//
+// which loads a new Config the same way as env.New(env.Sources(src)).Load
+// with the given prefix: the variables have the same names, the values are
+// parsed by the default parsers and the errors are the same. The types which
+// cannot be loaded without a loader, e.g. those using the tag options or
+// byte slices, are rejected.
+//
+// The generated loaders never use the parsers registered in
+// env.DefaultRegistry, e.g. by the init functions of the imported packages,
+// which take precedence over the default parsers in env.Load. So the loaders
+// agree only while no parsers are registered for the types of the struct.
+//
+// With -test, it also generates a test checking that the generated and the
+// reflective loaders agree.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "envgen:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string) error {
+	fs := flag.NewFlagSet("envgen", flag.ContinueOnError)
+	typeNames := fs.String("type", "", "comma-separated list of the struct `types`")
+	prefix := fs.String("prefix", "", "`prefix` of the variables")
+	output := fs.String("output", "", "output `file`; default <type>_env.go")
+	withTest := fs.Bool("test", false, "generate a test comparing the generated and reflective loaders")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *typeNames == "" {
+		return errors.New("missing -type")
+	}
+	dir := "."
+	switch fs.NArg() {
+	case 0:
+	case 1:
+		dir = fs.Arg(0)
+	default:
+		return errors.New("too many arguments")
+	}
+
+	names := strings.Split(*typeNames, ",")
+	if *output == "" {
+		*output = filepath.Join(dir, strings.ToLower(names[0])+"_env.go")
+	}
+	pkg, err := loadPackage(dir)
+	if err != nil {
+		return err
+	}
+	g := newGenerator(pkg, *prefix)
+	for _, name := range names {
+		if err := g.loader(name); err != nil {
+			return err
+		}
+		if *withTest {
+			g.test(name)
+		}
+	}
+	cmd := "envgen -type " + *typeNames
+	if *prefix != "" {
+		cmd += " -prefix " + *prefix
+	}
+	if *withTest {
+		cmd += " -test"
+	}
+	src, err := g.file(cmd, g.imports, g.body.String())
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(*output, src, 0644); err != nil {
+		return err
+	}
+	if !*withTest {
+		return nil
+	}
+	src, err = g.file(cmd, g.testImports, g.tests.String())
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(strings.TrimSuffix(*output, ".go")+"_test.go", src, 0644)
+}
+
+// loadPackage parses and type-checks the package in dir, without its tests.
+func loadPackage(dir string) (*types.Package, error) {
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, dir, func(fi os.FileInfo) bool {
+		return !strings.HasSuffix(fi.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("%s: expected one package, found %d", dir, len(pkgs))
+	}
+	var files []*ast.File
+	for _, p := range pkgs {
+		for _, f := range p.Files {
+			files = append(files, f)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		return fset.File(files[i].Pos()).Name() < fset.File(files[j].Pos()).Name()
+	})
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	return conf.Check(files[0].Name.Name, fset, files, nil)
+}
+
+// file formats a generated file with the given imports and body.
+func (g *generator) file(cmd string, imports map[string]bool, body string) ([]byte, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "// Code generated by %s; DO NOT EDIT.\n\n", cmd)
+	fmt.Fprintf(&b, "package %s\n\nimport (\n", g.pkg.Name())
+	paths := make([]string, 0, len(imports))
+	for path := range imports {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		if path != envPath {
+			fmt.Fprintf(&b, "\t%q\n", path)
+		}
+	}
+	if imports[envPath] {
+		fmt.Fprintf(&b, "\n\t%q\n", envPath)
+	}
+	b.WriteString(")\n\n")
+	b.WriteString(body)
+	return format.Source([]byte(b.String()))
+}
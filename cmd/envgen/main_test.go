@@ -0,0 +1,48 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestGenerated checks that the generated example is up to date.
+func TestGenerated(t *testing.T) {
+	a := assert.New(t)
+
+	out := filepath.Join(t.TempDir(), "config_env.go")
+	dir := filepath.Join("internal", "example")
+	a.NoError(run([]string{"-type", "Config", "-prefix", "APP_", "-test", "-output", out, dir}))
+	for _, name := range []string{"config_env.go", "config_env_test.go"} {
+		want, err := os.ReadFile(filepath.Join(dir, name))
+		a.NoError(err)
+		got, err := os.ReadFile(filepath.Join(filepath.Dir(out), name))
+		a.NoError(err)
+		a.Equal(string(want), string(got), name)
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	a := assert.New(t)
+
+	for src, want := range map[string]string{
+		"type T int":                                     "T must be a struct type",
+		"type T[P any] struct{}":                         "T must be a struct type without type parameters",
+		"type T struct{ A []byte `env:\"A\"` }":          "T.A: byte slices are not supported",
+		"type T struct{ a int `env:\"A\"` }":             "T.a: cannot write unexported field",
+		"type T struct{ A int `env:\"A,autobase\"` }":    `T.A: tag options are not supported: "autobase"`,
+		"type T struct{ A complex64 `env:\"A\"` }":       "T.A: parsing of complex64 not supported",
+		"type T struct{ A **int `env:\"A\"` }":           "T.A: pointers to pointers are not supported",
+		"type T struct{ A map[string]*int `env:\"A\"` }": "T.A: parsing of *int not supported",
+		"type T struct{ A [][]int `env:\"A\"` }":         "T.A: parsing of []int not supported",
+		"type T struct{ A U `env:\"A\"` }\ntype U struct{}\nfunc (*U) UnmarshalEnv(any) error { return nil }": "T.A: EnvUnmarshalers are not supported",
+		"type T struct{ N *T `env:\"N_\"` }": "T.N: recursive type p.T",
+		"type U struct{}":                    "type T not found",
+	} {
+		dir := t.TempDir()
+		a.NoError(os.WriteFile(filepath.Join(dir, "t.go"), []byte("package p\n"+src+"\n"), 0644))
+		a.EqualError(run([]string{"-type", "T", dir}), want, src)
+	}
+}
@@ -0,0 +1,99 @@
+// Command envtool manages the encrypted values of environment variables which
+// can be decrypted by loaders with the env.DecryptionKey option.
+//
+// Usage:
+//
+//	envtool keygen > key
+//	envtool encrypt -key key [value]
+//	envtool decrypt -key key [value]
+//
+// When no value is given, it's read from the standard input without the
+// trailing newlines.
+package main
+
+import (
+	"encoding/base64"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/Showmax/env"
+)
+
+const usage = `usage:
+  envtool keygen
+  envtool encrypt -key FILE [VALUE]
+  envtool decrypt -key FILE [VALUE]
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "envtool:", err)
+		}
+		os.Exit(2)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing command\n%s", usage)
+	}
+	cmd, args := args[0], args[1:]
+	switch cmd {
+	case "keygen":
+		key, err := env.GenerateKey()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, base64.StdEncoding.EncodeToString(key))
+		return err
+	case "encrypt", "decrypt":
+		fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+		keyFile := fs.String("key", "", "file with the base64 encoded `key`")
+		if err := fs.Parse(args); err != nil {
+			return err
+		}
+		if *keyFile == "" {
+			return errors.New("missing -key")
+		}
+		b, err := os.ReadFile(*keyFile)
+		if err != nil {
+			return err
+		}
+		key, err := env.ParseKey(string(b))
+		if err != nil {
+			return err
+		}
+		value, err := input(fs.Args(), stdin)
+		if err != nil {
+			return err
+		}
+		crypt := env.Encrypt
+		if cmd == "decrypt" {
+			crypt = env.Decrypt
+		}
+		out, err := crypt(key, value)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(stdout, out)
+		return err
+	}
+	return fmt.Errorf("unknown command %q\n%s", cmd, usage)
+}
+
+// input returns the only argument or the standard input.
+func input(args []string, stdin io.Reader) (string, error) {
+	switch len(args) {
+	case 0:
+		b, err := io.ReadAll(stdin)
+		return strings.TrimRight(string(b), "\r\n"), err
+	case 1:
+		return args[0], nil
+	}
+	return "", errors.New("too many arguments")
+}
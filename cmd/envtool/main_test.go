@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	a := assert.New(t)
+
+	var out bytes.Buffer
+	a.NoError(run([]string{"keygen"}, nil, &out))
+	keyFile := filepath.Join(t.TempDir(), "key")
+	a.NoError(os.WriteFile(keyFile, out.Bytes(), 0600))
+
+	out.Reset()
+	a.NoError(run([]string{"encrypt", "-key", keyFile}, strings.NewReader("hunter2\n"), &out))
+	enc := strings.TrimSpace(out.String())
+	a.True(strings.HasPrefix(enc, "enc:v1:"))
+
+	out.Reset()
+	a.NoError(run([]string{"decrypt", "-key", keyFile, enc}, nil, &out))
+	a.Equal("hunter2\n", out.String())
+
+	a.EqualError(run([]string{"encrypt", "x"}, nil, &out), "missing -key")
+	a.Error(run([]string{"rot13"}, nil, &out))
+	a.Error(run(nil, nil, &out))
+}
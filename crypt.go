@@ -0,0 +1,129 @@
+package env
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// encPrefix is the prefix of the encrypted values, followed by the base64
+// encoded nonce and ciphertext.
+const encPrefix = "enc:v1:"
+
+// KeySize is the size of the keys used to encrypt values, see Encrypt.
+const KeySize = 32
+
+var errKeySize = fmt.Errorf("key must be %d bytes long", KeySize)
+
+// GenerateKey returns a new random key for Encrypt.
+func GenerateKey() ([]byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// Encrypt encrypts plaintext by AES-256-GCM with key, which must be KeySize
+// bytes long. The result looks like enc:v1:<base64> and it can be decrypted
+// by a loader with the Decrypt option or by the Decrypt function.
+func Encrypt(key []byte, plaintext string) (string, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", err
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return encPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value encrypted by Encrypt with the same key.
+func Decrypt(key []byte, value string) (string, error) {
+	if !strings.HasPrefix(value, encPrefix) {
+		return "", errors.New("not an encrypted value")
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(value[len(encPrefix):])
+	if err != nil {
+		return "", fmt.Errorf("invalid encrypted value: %w", err)
+	}
+	if len(sealed) < aead.NonceSize() {
+		return "", errors.New("invalid encrypted value: too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return "", errors.New("cannot decrypt: wrong key or corrupted value")
+	}
+	return string(plaintext), nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errKeySize
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// DecryptionKey makes the loader decrypt the values encrypted by Encrypt with
+// key. The encrypted values are resolved like the other references (see
+// ResolveScheme), so they can be stored in any source, e.g. in dotenv files
+// committed to a repository.
+func DecryptionKey(key []byte) Option {
+	return ResolveScheme("enc", decryptResolver(func() ([]byte, error) {
+		return key, nil
+	}))
+}
+
+// DecryptionKeyFile is the same as DecryptionKey but the key is read from the
+// file at path by each load. The file contains the base64 encoded key, see
+// ParseKey.
+func DecryptionKeyFile(path string) Option {
+	return ResolveScheme("enc", decryptResolver(func() ([]byte, error) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return ParseKey(string(b))
+	}))
+}
+
+// ParseKey decodes a base64 encoded key, ignoring the surrounding white space.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("invalid key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, errKeySize
+	}
+	return key, nil
+}
+
+func decryptResolver(key func() ([]byte, error)) Resolver {
+	return ResolverFunc(func(_ context.Context, ref *url.URL) (string, error) {
+		k, err := key()
+		if err != nil {
+			return "", fmt.Errorf("cannot read decryption key: %w", err)
+		}
+		return Decrypt(k, ref.String())
+	})
+}
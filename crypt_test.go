@@ -0,0 +1,90 @@
+package env
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncrypt(t *testing.T) {
+	a := assert.New(t)
+
+	key, err := GenerateKey()
+	a.NoError(err)
+	a.Len(key, KeySize)
+
+	enc, err := Encrypt(key, "hunter2")
+	a.NoError(err)
+	a.True(strings.HasPrefix(enc, "enc:v1:"))
+	enc2, err := Encrypt(key, "hunter2")
+	a.NoError(err)
+	a.NotEqual(enc, enc2)
+
+	dec, err := Decrypt(key, enc)
+	a.NoError(err)
+	a.Equal("hunter2", dec)
+
+	other, _ := GenerateKey()
+	_, err = Decrypt(other, enc)
+	a.EqualError(err, "cannot decrypt: wrong key or corrupted value")
+	_, err = Decrypt(key, "enc:v1:AAAA")
+	a.EqualError(err, "invalid encrypted value: too short")
+	_, err = Decrypt(key, "hunter2")
+	a.EqualError(err, "not an encrypted value")
+	_, err = Encrypt(key[1:], "hunter2")
+	a.EqualError(err, "key must be 32 bytes long")
+}
+
+func TestDecryptionKey(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Password string `env:"PASSWORD"`
+		Port     int    `env:"PORT"`
+		User     string `env:"USER"`
+	}
+
+	key, _ := GenerateKey()
+	password, _ := Encrypt(key, "hunter2")
+	port, _ := Encrypt(key, "http")
+	src := Map("test", map[string]string{
+		"APP_PASSWORD": password,
+		"APP_PORT":     port,
+		"APP_USER":     "joe",
+	})
+
+	var c cfg
+	err := New(Sources(src), DecryptionKey(key)).Load(&c, "APP_")
+	a.Error(err)
+	a.Contains(err.Error(), `"APP_PORT" (test -> enc:v1:`)
+	a.Contains(err.Error(), `cannot parse <redacted> as int`)
+	a.NotContains(err.Error(), "http")
+
+	port, _ = Encrypt(key, "8080")
+	src = Map("test", map[string]string{
+		"APP_PASSWORD": password,
+		"APP_PORT":     port,
+		"APP_USER":     "joe",
+	})
+	a.NoError(New(Sources(src), DecryptionKey(key)).Load(&c, "APP_"))
+	a.Equal(cfg{"hunter2", 8080, "joe"}, c)
+
+	keyFile := filepath.Join(t.TempDir(), "key")
+	a.NoError(os.WriteFile(keyFile, []byte(base64.StdEncoding.EncodeToString(key)+"\n"), 0600))
+	c = cfg{}
+	a.NoError(New(Sources(src), DecryptionKeyFile(keyFile)).Load(&c, "APP_"))
+	a.Equal(cfg{"hunter2", 8080, "joe"}, c)
+
+	other, _ := GenerateKey()
+	err = New(Sources(src), DecryptionKey(other)).Load(&c, "APP_")
+	a.Error(err)
+	a.Contains(err.Error(), "cannot decrypt: wrong key or corrupted value")
+
+	err = New(Sources(src), DecryptionKeyFile(keyFile+".missing")).Load(&c, "APP_")
+	a.Error(err)
+	a.Contains(err.Error(), "cannot read decryption key")
+}
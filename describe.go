@@ -0,0 +1,211 @@
+package env
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+)
+
+// VarInfo describes a variable which a configuration struct is loaded from.
+type VarInfo struct {
+	// Name is the name of the variable, including the prefix. For maps,
+	// it's the prefix of the names of the variables holding the map items.
+	Name string
+
+	// Field is the path to the struct field, e.g. "DB.User".
+	Field string
+
+	// Type is the Go type of the field.
+	Type string
+
+	// Map is true if the field is a map, see Name.
+	Map bool
+
+	// Choices are the allowed values of enumerations, see RegisterEnum.
+	Choices []string
+
+	// Desc is the description from the desc tag of the field.
+	Desc string
+
+	// Aliases are the deprecated names of the variable, see the alias tag
+	// option.
+	Aliases []string
+
+	// RequiredIf is the condition making the variable required, e.g.
+	// "PREFIX_TLS_ENABLED" or "PREFIX_MODE=tcp", see the requiredIf tag
+	// option.
+	RequiredIf string
+
+	// Excludes are the variables which cannot be set together with the
+	// variable, see the excludes tag option.
+	Excludes []string
+
+	// OneOf is the group of variables of which exactly one must be set, see
+	// the oneOf tag option.
+	OneOf string
+
+	// Section is the prefix of the optional section the variable is in,
+	// which is loaded only if some variables with the prefix are set, see
+	// the optional tag option.
+	Section string
+
+	// Variant is the condition selecting the variant the variable belongs
+	// to, e.g. "PREFIX_STORAGE_KIND=s3", see RegisterVariants.
+	Variant string
+}
+
+// Describe returns the variables which dst would be loaded from by
+// Load(dst, prefix). Unlike Load, dst is never modified and it may be a nil
+// struct pointer.
+func (l *Loader) Describe(dst interface{}, prefix string) ([]VarInfo, error) {
+	rt := reflect.TypeOf(dst)
+	if rt == nil {
+		return nil, &loadError{[]error{errInvalidDst}}
+	}
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	var vars []VarInfo
+	errs := l.describe(&vars, reflect.New(rt), prefix, "", "")
+	if len(errs) > 0 {
+		return nil, &loadError{errs}
+	}
+	return vars, nil
+}
+
+// describe appends the variables of the struct rv to vars. The paths of the
+// fields are prefixed by path and the variables belong to the variant.
+func (l *Loader) describe(vars *[]VarInfo, rv reflect.Value, prefix, path, variant string) []error {
+	return l.walk(rv, prefix, allocate, func(f field) error {
+		if path != "" {
+			f.path = path + "." + f.path
+		}
+		rt := f.value.Type()
+		vs := l.variantsOf(rt)
+		if vs == nil {
+			*vars = append(*vars, VarInfo{
+				Name:       f.name,
+				Field:      f.path,
+				Type:       rt.String(),
+				Map:        isMap(rt),
+				Choices:    l.enumChoices(rt),
+				Desc:       f.tag.Get("desc"),
+				Aliases:    f.aliases,
+				Variant:    variant,
+				RequiredIf: f.rules.requiredIf(),
+				Excludes:   f.rules.excludes,
+				OneOf:      f.rules.group,
+				Section:    f.section,
+			})
+			return nil
+		}
+		name := kindName(f)
+		*vars = append(*vars, VarInfo{
+			Name:    name,
+			Field:   f.path,
+			Type:    rt.String(),
+			Choices: vs.kinds,
+			Desc:    f.tag.Get("desc"),
+			Section: f.section,
+			Variant: variant,
+		})
+		var errs []error
+		for _, kind := range vs.kinds {
+			vprefix := f.name + variantPrefix(kind)
+			cond := name + "=" + kind
+			errs = append(errs, l.describe(vars, reflect.New(vs.types[kind]), vprefix, f.path, cond)...)
+		}
+		if len(errs) > 0 {
+			return &loadError{errs}
+		}
+		return nil
+	})
+}
+
+// Describe describes dst using a Loader with no options, see Loader.Describe.
+func Describe(dst interface{}, prefix string) ([]VarInfo, error) {
+	return New().Describe(dst, prefix)
+}
+
+// Usage writes a table of the variables which dst would be loaded from to w.
+func (l *Loader) Usage(w io.Writer, dst interface{}, prefix string) error {
+	vars, err := l.Describe(dst, prefix)
+	if err != nil {
+		return err
+	}
+	return WriteUsage(w, vars)
+}
+
+// WriteUsage writes a table of vars to w, one variable per line.
+func WriteUsage(w io.Writer, vars []VarInfo) error {
+	rows := [][]string{{"VARIABLE", "TYPE", "DESCRIPTION"}}
+	for _, v := range vars {
+		name := v.Name
+		if v.Map {
+			name += "<KEY>"
+		}
+		desc := describeVar(v.Desc, v.Choices)
+		for _, cond := range conditions(v) {
+			desc = strings.TrimSpace(desc + " (" + cond + ")")
+		}
+		rows = append(rows, []string{name, v.Type, desc})
+	}
+	return writeTable(w, rows)
+}
+
+// conditions returns the descriptions of the conditions on v.
+func conditions(v VarInfo) []string {
+	var conds []string
+	if v.Variant != "" {
+		conds = append(conds, "if "+v.Variant)
+	}
+	if v.Section != "" {
+		conds = append(conds, "if any "+v.Section+"* is set")
+	}
+	if v.RequiredIf != "" {
+		conds = append(conds, "required if "+v.RequiredIf)
+	}
+	if len(v.Excludes) > 0 {
+		conds = append(conds, "excludes "+strings.Join(v.Excludes, ", "))
+	}
+	if v.OneOf != "" {
+		conds = append(conds, "one of group "+v.OneOf)
+	}
+	return conds
+}
+
+// describeVar returns the description of a variable, completed by the allowed
+// values of enumerations.
+func describeVar(desc string, choices []string) string {
+	if len(choices) == 0 {
+		return desc
+	}
+	list := "one of: " + strings.Join(choices, ", ")
+	return strings.TrimSpace(desc + " (" + list + ")")
+}
+
+// writeTable writes rows to w as a table with aligned columns.
+func writeTable(w io.Writer, rows [][]string) error {
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	// Don't pad the lines with empty last column.
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		trimmed := strings.TrimRight(line, " \n")
+		if trimmed == "" {
+			continue
+		}
+		if _, err := io.WriteString(w, trimmed+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DirOption modifies the behavior of Dir.
+type DirOption func(d *dirOptions)
+
+type dirOptions struct {
+	prefix       string
+	keepNewlines bool
+}
+
+// DirPrefix prepends prefix to the file names to get the variable names, so
+// e.g. the file db_password holds the variable PREFIX_db_password.
+func DirPrefix(prefix string) DirOption {
+	return func(d *dirOptions) {
+		d.prefix = prefix
+	}
+}
+
+// DirKeepNewlines keeps the trailing newlines of the file contents, which are
+// trimmed by default.
+func DirKeepNewlines() DirOption {
+	return func(d *dirOptions) {
+		d.keepNewlines = true
+	}
+}
+
+// Dir returns a source holding the variables from the directory at path, one
+// file per variable. The file names are the variable names (see DirPrefix) and
+// the contents, without the trailing newlines (see DirKeepNewlines), are the
+// values. This is how Kubernetes mounts ConfigMaps and Secrets as volumes and
+// how systemd passes credentials to services, see SystemdCredentials.
+//
+// Hidden files (starting with .) and subdirectories are ignored. Symbolic
+// links are followed, so the layout Kubernetes uses for atomic updates, where
+// the files are links to the hidden ..data directory, is supported.
+//
+// The directory is read by Dir and then again whenever the source is
+// refreshed, see Refresher. The values have the origin "dir" with the file.
+func Dir(path string, opts ...DirOption) (Source, error) {
+	var o dirOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	fs, err := newFileSource(func() (mapSource, error) {
+		return readDir(path, o)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// SystemdCredentials returns a source holding the credentials passed to the
+// service by systemd, see Dir. The credentials are read from the directory
+// given by the CREDENTIALS_DIRECTORY variable, which must be set.
+func SystemdCredentials(opts ...DirOption) (Source, error) {
+	path, ok := os.LookupEnv("CREDENTIALS_DIRECTORY")
+	if !ok {
+		return nil, errors.New("env: CREDENTIALS_DIRECTORY is not set")
+	}
+	return Dir(path, opts...)
+}
+
+// readDir reads the directory at path, see Dir.
+func readDir(path string, o dirOptions) (mapSource, error) {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	m := make(mapSource, len(entries))
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		file := filepath.Join(path, e.Name())
+		// Follow the links; the entry itself doesn't tell whether the
+		// link points to a regular file.
+		fi, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		b, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		value := string(b)
+		if !o.keepNewlines {
+			value = strings.TrimRight(value, "\r\n")
+		}
+		origin := Origin{Source: "dir", File: file}
+		m[o.prefix+e.Name()] = Var{value, origin}
+	}
+	return m, nil
+}
@@ -0,0 +1,116 @@
+package env
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeKubeVolume writes files to dir using the layout of Kubernetes volumes:
+// the files are stored in a hidden timestamped directory, which is linked by
+// ..data, and the visible files are links to ..data.
+func writeKubeVolume(t *testing.T, dir, version string, files map[string]string) {
+	t.Helper()
+	must := func(err error) {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	ts := filepath.Join(dir, "..2021_05_03_"+version)
+	must(os.Mkdir(ts, 0700))
+	for name, content := range files {
+		must(os.WriteFile(filepath.Join(ts, name), []byte(content), 0600))
+		link := filepath.Join(dir, name)
+		if _, err := os.Lstat(link); os.IsNotExist(err) {
+			must(os.Symlink(filepath.Join("..data", name), link))
+		}
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	must(os.Symlink(filepath.Base(ts), tmp))
+	must(os.Rename(tmp, filepath.Join(dir, "..data")))
+}
+
+func TestDir(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	writeKubeVolume(t, dir, "1", map[string]string{
+		"DB_PASSWORD": "hunter2\n",
+		"CERT":        "-----BEGIN CERTIFICATE-----\n...\n",
+	})
+	a.NoError(os.WriteFile(filepath.Join(dir, ".hidden"), []byte("x"), 0600))
+	a.NoError(os.Mkdir(filepath.Join(dir, "subdir"), 0700))
+
+	src, err := Dir(dir, DirPrefix("APP_"))
+	a.NoError(err)
+	a.Equal([]string{"APP_CERT", "APP_DB_PASSWORD"}, src.Names())
+
+	v, ok := src.Lookup("APP_DB_PASSWORD")
+	a.True(ok)
+	a.Equal("hunter2", v.Value)
+	a.Equal(Origin{Source: "dir", File: filepath.Join(dir, "DB_PASSWORD")}, v.Origin)
+	v, _ = src.Lookup("APP_CERT")
+	a.Equal("-----BEGIN CERTIFICATE-----\n...", v.Value)
+
+	// Kubernetes updates the files by switching the ..data link.
+	writeKubeVolume(t, dir, "2", map[string]string{
+		"DB_PASSWORD": "correct horse battery staple\n",
+		"CERT":        "-----BEGIN CERTIFICATE-----\n...\n",
+	})
+	changed, err := src.(Refresher).Refresh()
+	a.NoError(err)
+	a.True(changed)
+	v, _ = src.Lookup("APP_DB_PASSWORD")
+	a.Equal("correct horse battery staple", v.Value)
+
+	changed, err = src.(Refresher).Refresh()
+	a.NoError(err)
+	a.False(changed)
+}
+
+func TestDirKeepNewlines(t *testing.T) {
+	a := assert.New(t)
+
+	dir := t.TempDir()
+	a.NoError(os.WriteFile(filepath.Join(dir, "KEY"), []byte("value\n\n"), 0600))
+
+	src, err := Dir(dir, DirKeepNewlines())
+	a.NoError(err)
+	v, ok := src.Lookup("KEY")
+	a.True(ok)
+	a.Equal("value\n\n", v.Value)
+
+	_, err = Dir(filepath.Join(dir, "missing"))
+	a.Error(err)
+}
+
+func TestDirLayered(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		User     string `env:"DB_USER"`
+		Password string `env:"DB_PASSWORD"`
+	}
+
+	dir := t.TempDir()
+	a.NoError(os.WriteFile(filepath.Join(dir, "DB_PASSWORD"), []byte("hunter2\n"), 0600))
+	a.NoError(os.WriteFile(filepath.Join(dir, "DB_USER"), []byte("root\n"), 0600))
+
+	os.Clearenv()
+	os.Setenv("APP_DB_USER", "joe")
+	os.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	creds, err := SystemdCredentials(DirPrefix("APP_"))
+	a.NoError(err)
+
+	var c cfg
+	a.NoError(New(Sources(OSEnv(), creds)).Load(&c, "APP_"))
+	a.Equal(cfg{"joe", "hunter2"}, c)
+
+	os.Unsetenv("CREDENTIALS_DIRECTORY")
+	_, err = SystemdCredentials()
+	a.Error(err)
+}
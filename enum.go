@@ -0,0 +1,87 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// RegisterEnum registers the values of an enumeration type T, given as
+// a map[string]T from the names of the values to the values themselves. The
+// fields of type T are then parsed by the names, e.g.:
+//
+//	l.RegisterEnum(map[string]Mode{
+//		"fast": ModeFast,
+//		"slow": ModeSlow,
+//	})
+//
+// The names are matched exactly. RegisterEnum panics if values is not a map
+// with string keys.
+func (l *Loader) RegisterEnum(values interface{}) {
+	l.registerEnum(values, false)
+}
+
+// RegisterEnumFold is the same as RegisterEnum but the names are matched
+// case-insensitively. It panics if two names differ only in case.
+func (l *Loader) RegisterEnumFold(values interface{}) {
+	l.registerEnum(values, true)
+}
+
+func (l *Loader) registerEnum(values interface{}, fold bool) {
+	rv := reflect.ValueOf(values)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		panic(fmt.Sprintf("env: enum values must be map[string]T, not %T", values))
+	}
+	lookup := make(map[string]interface{}, rv.Len())
+	names := make([]string, 0, rv.Len())
+	iter := rv.MapRange()
+	for iter.Next() {
+		name := iter.Key().String()
+		key := name
+		if fold {
+			key = strings.ToLower(name)
+		}
+		if _, ok := lookup[key]; ok {
+			panic(fmt.Sprintf("env: enum value %q registered twice", name))
+		}
+		lookup[key] = iter.Value().Interface()
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	rt := rv.Type().Elem()
+	l.mu.Lock()
+	l.enums[rt] = names
+	l.mu.Unlock()
+	l.AddParser(rt, func(s string) (interface{}, error) {
+		key := s
+		if fold {
+			key = strings.ToLower(s)
+		}
+		if v, ok := lookup[key]; ok {
+			return v, nil
+		}
+		choices := strings.Join(names, ", ")
+		return nil, fmt.Errorf("must be one of: %s", choices)
+	})
+}
+
+// enumChoices returns the names of the values of the enumeration contained in
+// rt, which may be the enumeration type itself or a pointer to or a slice of
+// it. If there's no such enumeration, nil is returned.
+func (l *Loader) enumChoices(rt reflect.Type) []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	for {
+		if names, ok := l.enums[rt]; ok {
+			return names
+		}
+		switch rt.Kind() {
+		case reflect.Ptr, reflect.Slice:
+			rt = rt.Elem()
+		default:
+			return nil
+		}
+	}
+}
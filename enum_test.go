@@ -0,0 +1,100 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mode int
+
+const (
+	modeFast mode = iota
+	modeSlow
+)
+
+func TestEnum(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Mode  mode   `env:"MODE"`
+		Modes []mode `env:"MODES"`
+	}
+
+	l := New()
+	l.RegisterEnum(map[string]mode{
+		"fast": modeFast,
+		"slow": modeSlow,
+	})
+
+	os.Clearenv()
+	os.Setenv("MODE", "slow")
+	os.Setenv("MODES", "fast,slow")
+
+	var c cfg
+	a.NoError(l.Load(&c, ""))
+	a.Equal(cfg{modeSlow, []mode{modeFast, modeSlow}}, c)
+
+	os.Setenv("MODE", "Slow")
+	err := l.Load(&c, "")
+	a.EqualError(err, `env: cannot load environment config: "MODE" (env): `+
+		`cannot parse "Slow" as env.mode: must be one of: fast, slow`)
+}
+
+func TestEnumFold(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Mode mode `env:"MODE"`
+	}
+
+	l := New()
+	l.RegisterEnumFold(map[string]mode{
+		"fast": modeFast,
+		"slow": modeSlow,
+	})
+
+	os.Clearenv()
+	os.Setenv("MODE", "SLOW")
+
+	var c cfg
+	a.NoError(l.Load(&c, ""))
+	a.Equal(modeSlow, c.Mode)
+
+	a.Panics(func() {
+		l.RegisterEnumFold(map[string]mode{"a": 0, "A": 1})
+	})
+	a.Panics(func() {
+		l.RegisterEnum([]mode{modeFast})
+	})
+}
+
+func ExampleLoader_Usage() {
+	type dbConfig struct {
+		User string `env:"USER" desc:"Database user."`
+		Pass string `env:"PASS"`
+	}
+
+	type config struct {
+		DB     dbConfig          `env:"DB_"`
+		Mode   mode              `env:"MODE" desc:"Processing mode."`
+		Labels map[string]string `env:"LABEL_"`
+	}
+
+	l := New()
+	l.RegisterEnum(map[string]mode{
+		"fast": modeFast,
+		"slow": modeSlow,
+	})
+	if err := l.Usage(os.Stdout, (*config)(nil), "EXAMPLE_"); err != nil {
+		fmt.Println(err)
+	}
+	// Output:
+	// VARIABLE             TYPE               DESCRIPTION
+	// EXAMPLE_DB_USER      string             Database user.
+	// EXAMPLE_DB_PASS      string
+	// EXAMPLE_MODE         env.mode           Processing mode. (one of: fast, slow)
+	// EXAMPLE_LABEL_<KEY>  map[string]string
+}
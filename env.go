@@ -3,6 +3,7 @@
 package env
 
 import (
+	"context"
 	"encoding"
 	"errors"
 	"fmt"
@@ -10,16 +11,22 @@ import (
 	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	tt "text/template"
 	"time"
 	"unicode"
 )
 
-// parseFunc takes a string and coerces it into some target type. If coercion
+// ParseFunc takes a string and coerces it into some target type. If coercion
 // fails, an error is returned.
-type parseFunc func(s string) (interface{}, error)
+type ParseFunc func(s string) (interface{}, error)
+
+// ErrMissing is the error of the variables which are missing, wrapped by
+// a VarError.
+var ErrMissing = errors.New("variable missing")
 
 var (
 	errInvalidDst    = errors.New("dst must be struct or struct pointer")
@@ -30,6 +37,43 @@ type loadError struct {
 	errs []error
 }
 
+// VarError is an error of a single variable.
+type VarError struct {
+	// Name is the name of the variable, including the prefix.
+	Name string
+
+	// Origin is the origin of the value of the variable, if it exists.
+	Origin *Origin
+
+	Err error
+}
+
+func (e *VarError) Error() string {
+	if e.Origin != nil {
+		return fmt.Sprintf("%q (%s): %v", e.Name, e.Origin, e.Err)
+	}
+	return fmt.Sprintf("%q: %v", e.Name, e.Err)
+}
+
+func (e *VarError) Unwrap() error {
+	return e.Err
+}
+
+// asVarError wraps err to a VarError of the variable called name, unless it
+// already is one.
+func asVarError(name string, err error) error {
+	if _, ok := err.(*VarError); ok {
+		return err
+	}
+	return &VarError{Name: name, Err: err}
+}
+
+// Unwrap returns the errors of the load, so that errors.Is and errors.As find
+// e.g. the VarErrors.
+func (e *loadError) Unwrap() []error {
+	return e.errs
+}
+
 func (e *loadError) Error() string {
 	errStr := "env: cannot load environment config: "
 	for i, err := range e.errs {
@@ -42,39 +86,204 @@ func (e *loadError) Error() string {
 }
 
 // Load will load configuration from environment to dst, which must be a struct
-// or a struct pointer.
+// or a struct pointer. It uses a Loader with no options.
 func Load(dst interface{}, prefix string) error {
-	return newLoader().Load(dst, prefix)
+	return New().Load(dst, prefix)
 }
 
-// loader is used to load the environment.
-type loader struct {
-	parsers map[reflect.Type]parseFunc
+// Loader is used to load the environment. It's safe for concurrent use,
+// including adding parsers while loading.
+type Loader struct {
+	// mu guards parsers, enums, variants and plans.
+	mu sync.RWMutex
+
+	// parsers are the parsers added to the loader, which take precedence
+	// over the registry and the default parsers.
+	parsers map[reflect.Type]ParseFunc
+
+	// registry is used instead of DefaultRegistry, see WithRegistry.
+	registry *Registry
+
+	// enums holds the sorted names of the values of the types registered
+	// by RegisterEnum.
+	enums map[reflect.Type][]string
+
+	// variants are the variants of the interface types registered by
+	// RegisterVariants.
+	variants map[reflect.Type]*variants
+
+	// naming derives the names of the variables of untagged fields, see
+	// Naming. If nil, untagged fields are not loaded.
+	naming func(field string) string
+
+	// src provides the variables, see Sources.
+	src Source
+
+	// partial disables transactional loads, see PartialFill.
+	partial bool
+
+	// onWarning is called with the warnings, see OnWarning.
+	onWarning func(w Warning)
+
+	// strict enables the warnings about the unused variables, see Strict.
+	strict bool
+
+	// scrub tells which variables the successful loads unset from the
+	// environment, see ScrubEnv.
+	scrub scrubMode
+
+	// reportErrors writes the errors of MustLoad, see ReportErrors.
+	reportErrors ReportFunc
+
+	// onEvent are the event hooks, see OnEvent.
+	onEvent []func(e Event)
+
+	// resolvers resolve the references by their URI schemes, see
+	// ResolveScheme.
+	resolvers      map[string]Resolver
+	resolveTimeout time.Duration
+
+	// plans caches the compiled plans of the walked structs by their
+	// types and prefixes, see plan. The loaders with no parsers of their
+	// own, no registry and no naming share defaultPlans.
+	plans *sync.Map
 }
 
-// newLoader returns a loader with a default set of parsers.
-func newLoader() *loader {
-	return &loader{defaultParsers()}
+var defaultPlans sync.Map
+
+// New returns a Loader with a default set of parsers, modified by opts.
+func New(opts ...Option) *Loader {
+	l := &Loader{
+		parsers: make(map[reflect.Type]ParseFunc),
+		enums:   make(map[reflect.Type][]string),
+		src:     OSEnv(),
+		plans:   &defaultPlans,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
 }
 
 // Load will load configuration from environment to dst, which must be a struct
 // or a struct pointer.
-func (l *loader) Load(dst interface{}, prefix string) error {
-	errs := l.loadStruct(reflect.ValueOf(dst), prefix)
-	if len(errs) > 0 {
+func (l *Loader) Load(dst interface{}, prefix string) error {
+	_, err := l.Explain(dst, prefix)
+	return err
+}
+
+// LoadContext is the same as Load but ctx is passed to the resolvers, see
+// ResolveScheme.
+func (l *Loader) LoadContext(ctx context.Context, dst interface{}, prefix string) error {
+	s := l.newLoadState(ctx)
+	if errs := s.load(dst, prefix); len(errs) > 0 {
 		return &loadError{errs}
 	}
 	return nil
 }
 
+// loadState is the state of a single load.
+type loadState struct {
+	*Loader
+
+	ctx context.Context
+
+	// src provides the variables for this load.
+	src Source
+
+	// report collects the provenance of the loaded variables.
+	report Report
+
+	// refs are the references resolved by the load, guarded by refMu, and
+	// refCtx limits the time spent by resolving them, see lookupVar.
+	refs   map[string]resolution
+	refMu  sync.Mutex
+	refCtx context.Context
+
+	// copyOnWrite is set in transactional loads and copied holds the
+	// pointers already copied, see follow.
+	copyOnWrite bool
+	copied      map[uintptr]bool
+}
+
+func (l *Loader) newLoadState(ctx context.Context) *loadState {
+	return &loadState{Loader: l, ctx: ctx, src: l.src}
+}
+
 // AddParser will register a custom parser f which will be used to load all
 // instances of rt from environment.
-func (l *loader) AddParser(rt reflect.Type, f parseFunc) {
+func (l *Loader) AddParser(rt reflect.Type, f ParseFunc) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.parsers[rt] = f
+	l.plans = new(sync.Map)
 }
 
-func (l *loader) hasParser(rt reflect.Type) bool {
-	_, ok := l.parsers[rt]
+// parser returns the parser for rt. The tag options may select a different
+// parser than the one registered in the loader.
+func (l *Loader) parser(rt reflect.Type, opts tagOptions) ParseFunc {
+	if opts.autoBase {
+		if f := autoBaseParsers[rt]; f != nil {
+			return f
+		}
+	}
+	if opts.extBool {
+		if f := extBoolParsers[rt]; f != nil {
+			return f
+		}
+	}
+	f, _ := l.lookupParser(rt)
+	return f
+}
+
+// lookupParser returns the parser of rt added to the loader, registered in its
+// registry or the default one, in this order.
+func (l *Loader) lookupParser(rt reflect.Type) (ParseFunc, bool) {
+	l.mu.RLock()
+	f, ok := l.parsers[rt]
+	l.mu.RUnlock()
+	if ok {
+		return f, true
+	}
+	if f, ok := l.reg().parser(rt); ok {
+		return f, true
+	}
+	f, ok = builtinParsers[rt]
+	return f, ok
+}
+
+// fieldParser returns the parser of a field of type rt, which is the parser of
+// the type the pointers are followed to unless rt has a parser itself.
+func (l *Loader) fieldParser(rt reflect.Type, opts tagOptions) ParseFunc {
+	if !l.hasParser(rt) {
+		for rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+	}
+	return l.parser(rt, opts)
+}
+
+// appliesTo tells whether rt, after following the pointers, or the type of its
+// items or map keys satisfies ok. It checks the tag options which apply only
+// to some types, e.g. encoding.
+func appliesTo(rt reflect.Type, ok func(rt reflect.Type) bool) bool {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if ok(rt) {
+		return true
+	}
+	switch rt.Kind() {
+	case reflect.Slice, reflect.Array:
+		return appliesTo(rt.Elem(), ok)
+	case reflect.Map:
+		return appliesTo(rt.Key(), ok) || appliesTo(rt.Elem(), ok)
+	}
+	return false
+}
+
+func (l *Loader) hasParser(rt reflect.Type) bool {
+	_, ok := l.lookupParser(rt)
 	return ok
 }
 
@@ -85,82 +294,563 @@ func isExported(f reflect.StructField) bool {
 	panic("bug: f.Name cannot be empty")
 }
 
-func (l *loader) loadStruct(rv reflect.Value, prefix string) []error {
+// load loads dst from a snapshot of the source. Unless partial loads are
+// enabled, the values are loaded to a copy of the destination struct which is
+// written to dst only when there are no errors. A successful load scrubs the
+// environment, see ScrubEnv.
+func (s *loadState) load(dst interface{}, prefix string) []error {
+	errs := s.loadDst(dst, prefix)
+	if len(errs) == 0 {
+		s.scrubEnv()
+	}
+	return errs
+}
+
+func (s *loadState) loadDst(dst interface{}, prefix string) []error {
+	s.src = takeSnapshot(s.src)
+	if len(s.resolvers) > 0 {
+		defer s.resolving()()
+		s.prefetch(s.readNames(reflect.TypeOf(dst), prefix))
+	}
+	rv := reflect.ValueOf(dst)
+	if s.partial || rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return s.loadStruct(rv, prefix)
+	}
+	// Find the destination without allocating anything.
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct && rv.Kind() != reflect.Ptr {
+		return []error{errInvalidDst}
+	}
+	scratch := reflect.New(rv.Type())
+	scratch.Elem().Set(rv)
+	s.copyOnWrite = true
+	errs := s.loadStruct(scratch, prefix)
+	if len(errs) == 0 {
+		rv.Set(scratch.Elem())
+	}
+	return errs
+}
+
+func (s *loadState) loadStruct(rv reflect.Value, prefix string) []error {
+	errs := s.walk(rv, prefix, s.deref, s.loadVar)
+	if len(errs) == 1 && errs[0] == errInvalidDst {
+		return errs
+	}
+	errs = append(errs, s.checkRules(rv.Type(), prefix)...)
+	s.reportUnknown(rv.Type(), prefix)
+	return errs
+}
+
+// field is a struct field holding a single variable, found by walk.
+type field struct {
+	// name is the name of the variable, including the prefix.
+	name string
+
+	// aliases are the deprecated names of the variable, including the
+	// prefix, see the alias tag option.
+	aliases []string
+
+	// path is the path to the field from the walked struct, e.g.
+	// "DB.User".
+	path string
+
+	value reflect.Value
+	tag   reflect.StructTag
+	opts  tagOptions
+
+	// parse is the parser of the field, after following the pointers, if
+	// the loader has one.
+	parse ParseFunc
+
+	// rules are the conditional requirements of the field.
+	rules rules
+
+	// section is the prefix of the innermost optional section the field
+	// is in, if any, see the optional tag option.
+	section string
+}
+
+// walk calls fn for every variable in the struct rv, recursing into nested
+// structs. The pointers to the nested structs are followed by deref. The
+// errors returned by fn are collected together with the errors found in the
+// struct definition. If fn returns a loadError, its errors are collected one
+// by one.
+func (l *Loader) walk(rv reflect.Value, prefix string, deref derefFunc, fn func(f field) error) []error {
 	rv = follow(rv)
 	if rv.Kind() != reflect.Struct || !rv.CanAddr() {
 		return []error{errInvalidDst}
 	}
 	var errs []error
-	rt := rv.Type()
+	for _, pf := range l.plan(rv.Type(), prefix) {
+		if pf.err != nil {
+			errs = append(errs, pf.err)
+			continue
+		}
+		f := pf.field
+		var ok bool
+		if f.value, ok = pf.value(rv, deref); !ok {
+			continue
+		}
+		err := fn(f)
+		if le, ok := err.(*loadError); ok {
+			errs = append(errs, le.errs...)
+		} else if err != nil {
+			errs = append(errs, asVarError(f.name, err))
+		}
+	}
+	return errs
+}
+
+// planField is a field of a compiled plan, see Loader.plan. It's either
+// a variable or an error found in the struct definition.
+type planField struct {
+	field
+
+	// index is the index sequence of the field, see value.
+	index []int
+
+	// sections are the pointers to structs on the way to the field, see
+	// value.
+	sections []section
+
+	err error
+}
+
+// section is a pointer to a nested struct.
+type section struct {
+	// name is the prefix of the names of the variables in the struct.
+	name string
+
+	// optional is true if the pointer is left nil when no variable with
+	// the prefix is set, see the optional tag option.
+	optional bool
+}
+
+// derefFunc returns the struct the pointer rv to the section sec points to, or
+// false to skip the fields of the section.
+type derefFunc func(rv reflect.Value, sec section) (reflect.Value, bool)
+
+// allocate is a derefFunc allocating the nil pointers, see follow.
+func allocate(rv reflect.Value, _ section) (reflect.Value, bool) {
+	return follow(rv), true
+}
+
+// existing is a derefFunc skipping the nil pointers.
+func existing(rv reflect.Value, _ section) (reflect.Value, bool) {
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return rv, false
+		}
+		rv = rv.Elem()
+	}
+	return rv, true
+}
+
+// value returns the field pf of the struct rv, following the pointers to the
+// sections on the way by deref.
+func (pf planField) value(rv reflect.Value, deref derefFunc) (reflect.Value, bool) {
+	sections := pf.sections
+	for _, i := range pf.index {
+		if rv.Kind() == reflect.Ptr {
+			var ok bool
+			if rv, ok = deref(rv, sections[0]); !ok {
+				return rv, false
+			}
+			sections = sections[1:]
+		}
+		rv = rv.Field(i)
+	}
+	return rv, true
+}
+
+// optionalSection returns the name of the last optional section of sections,
+// or "" if there's none.
+func optionalSection(sections []section) string {
+	for i := len(sections) - 1; i >= 0; i-- {
+		if sections[i].optional {
+			return sections[i].name
+		}
+	}
+	return ""
+}
+
+// typeIn returns the type of the field pf of the struct type rt.
+func (pf planField) typeIn(rt reflect.Type) reflect.Type {
+	for _, i := range pf.index {
+		for rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+		rt = rt.Field(i).Type
+	}
+	return rt
+}
+
+func containsType(types []reflect.Type, rt reflect.Type) bool {
+	for _, t := range types {
+		if t == rt {
+			return true
+		}
+	}
+	return false
+}
+
+type planKey struct {
+	rt     reflect.Type
+	prefix string
+}
+
+// cachedPlan is a plan compiled with the given generation of a registry, see
+// Registry.
+type cachedPlan struct {
+	reg    *Registry
+	gen    uint64
+	fields []planField
+}
+
+// plan returns the fields of the struct type rt which are walked by walk. The
+// plans are compiled once for each type and prefix and cached by the loader
+// until its parsers or the parsers in its registry change.
+func (l *Loader) plan(rt reflect.Type, prefix string) []planField {
+	key := planKey{rt, prefix}
+	reg := l.reg()
+	gen := reg.generation()
+	l.mu.RLock()
+	plans := l.plans
+	l.mu.RUnlock()
+	if p, ok := plans.Load(key); ok {
+		if p := p.(cachedPlan); p.reg == reg && p.gen == gen {
+			return p.fields
+		}
+	}
+	p := l.compile(nil, rt, scope{prefix: prefix, types: []reflect.Type{rt}})
+	plans.Store(key, cachedPlan{reg, gen, p})
+	return p
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// scope is the position of a struct compiled by compile.
+type scope struct {
+	// index is the index sequence of the struct, see planField.value.
+	index []int
+
+	// prefix is the prefix of the names of the variables in the struct
+	// and path is the path to the struct, e.g. "DB".
+	prefix, path string
+
+	// rules are the rules inherited by the fields of the struct.
+	rules rules
+
+	// sections are the pointers to structs on the way to the struct.
+	sections []section
+
+	// types are the types of the structs the struct is in, the struct
+	// itself included, to detect the recursive types.
+	types []reflect.Type
+}
+
+// compile appends the fields of the struct type rt in sc to p.
+func (l *Loader) compile(p []planField, rt reflect.Type, sc scope) []planField {
+	prefix, path := sc.prefix, sc.path
 	for i := 0; i < rt.NumField(); i++ {
 		f := rt.Field(i)
 		// When the field has no env tag, we don't touch it at all.
 		// The only exception is anonymous structs to which we recurse.
+		// With a naming function, all exported fields are loaded. The
+		// fields tagged with "-" are always skipped.
 		tag, hasTag := f.Tag.Lookup("env")
-		isStruct := f.Type.Kind() == reflect.Struct
+		ft := f.Type
+		if !l.hasParser(ft) {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+		}
+		isStruct := ft.Kind() == reflect.Struct
 		isAnonStruct := isStruct && f.Anonymous
-		if !hasTag && !isAnonStruct {
+		if tag == "-" {
+			continue
+		}
+		if !hasTag && !isAnonStruct && (l.naming == nil || !isExported(f)) {
+			continue
+		}
+		if !hasTag && !isExported(f) && ft != f.Type && !l.hasEnvFields(ft, []reflect.Type{ft}) {
+			// The embedded pointers to unexported structs are left
+			// alone unless there's something to load in them.
 			continue
 		}
 		if !isExported(f) {
 			err := fmt.Errorf("%q: %w", f.Name, errUnexportedDst)
-			errs = append(errs, err)
+			p = append(p, planField{err: err})
 			continue
 		}
-		fv := rv.Field(i)
-		if !fv.CanInterface() || !fv.CanSet() {
-			err := fmt.Errorf("%q: %w", f.Name, errInvalidDst)
-			errs = append(errs, err)
+		tag, opts, err := parseTag(tag)
+		isEU := isEnvUnmarshaler(f.Type)
+		isVariant := l.variantsOf(f.Type) != nil
+		if err == nil && len(opts.unknown) > 0 && !isEU {
+			err = fmt.Errorf("unknown tag option %q", opts.unknown[0])
+		}
+		if err == nil && opts.kind != "" && !isVariant {
+			err = fmt.Errorf("kind option used for %s, which has no variants", f.Type)
+		}
+		if err == nil && opts.encoding != "" && !isEU && !appliesTo(f.Type, isBytes) {
+			err = fmt.Errorf("encoding option used for %s, which holds no bytes", f.Type)
+		}
+		if err == nil && opts.autoBase && !isEU && !appliesTo(f.Type, hasAutoBase) {
+			err = fmt.Errorf("autobase option used for %s, which holds no integers", f.Type)
+		}
+		if err == nil && opts.extBool && !isEU && !appliesTo(f.Type, hasExtBool) {
+			err = fmt.Errorf("extbool option used for %s, which holds no booleans", f.Type)
+		}
+		if err != nil {
+			p = append(p, planField{err: fmt.Errorf("%q: %w", f.Name, err)})
 			continue
 		}
+		if tag == "" && !f.Anonymous && l.naming != nil {
+			tag = l.naming(f.Name)
+		}
 		name := prefix + tag
-		isTU := (textUnmarshaler(fv) != nil)
-		hasParser := l.hasParser(f.Type)
-		if isStruct && !hasParser && !isTU {
-			// Recurse to the field which is a structure.
-			errs = append(errs, l.loadStruct(fv, name)...)
-		} else if err := l.loadVar(fv, name); err != nil {
-			errs = append(errs, fmt.Errorf("%q: %w", name, err))
+		fpath := f.Name
+		if path != "" {
+			fpath = path + "." + f.Name
 		}
+		findex := append(sc.index[:len(sc.index):len(sc.index)], i)
+		isTU := ft.Implements(textUnmarshalerType) ||
+			reflect.PtrTo(ft).Implements(textUnmarshalerType)
+		hasParser := l.hasParser(ft)
+		isPrefix := (isStruct && !hasParser && !isTU) || isMap(f.Type) || (isVariant && !hasParser)
+		if isPrefix && l.naming != nil && tag != "" && !strings.HasSuffix(name, "_") {
+			name += "_"
+		}
+		if isPrefix && len(opts.aliases) > 0 {
+			err := errors.New("aliases are not supported for maps and structs")
+			p = append(p, planField{err: fmt.Errorf("%q: %w", f.Name, err)})
+			continue
+		}
+		isNested := isStruct && !hasParser && !isTU && !isEU
+		r, err := compileRules(sc.rules, opts, prefix, fpath, isPrefix || isEU, isNested)
+		if err == nil && opts.optional && (!isNested || ft == f.Type) {
+			err = errors.New("optional option is supported only for pointers to structs")
+		}
+		if err == nil && isNested && containsType(sc.types, ft) {
+			err = fmt.Errorf("recursive type %s", ft)
+		}
+		if err != nil {
+			p = append(p, planField{err: fmt.Errorf("%q: %w", f.Name, err)})
+			continue
+		}
+		aliases := make([]string, len(opts.aliases))
+		for i, alias := range opts.aliases {
+			aliases[i] = prefix + alias
+		}
+		if isNested {
+			// Recurse to the field which is a structure or a pointer to
+			// it.
+			sections := sc.sections
+			if ft != f.Type {
+				sec := section{name: name, optional: opts.optional}
+				sections = append(sections[:len(sections):len(sections)], sec)
+			}
+			p = l.compile(p, ft, scope{
+				index:    findex,
+				prefix:   name,
+				path:     fpath,
+				rules:    r,
+				sections: sections,
+				types:    append(sc.types[:len(sc.types):len(sc.types)], ft),
+			})
+			continue
+		}
+		p = append(p, planField{
+			field: field{
+				name:    name,
+				aliases: aliases,
+				path:    fpath,
+				tag:     f.Tag,
+				opts:    opts,
+				parse:   l.fieldParser(f.Type, opts),
+				rules:   r,
+				section: optionalSection(sc.sections),
+			},
+			index:    findex,
+			sections: sc.sections,
+		})
 	}
-	return errs
+	return p
 }
 
-func (l *loader) loadVar(rv reflect.Value, name string) error {
-	if !l.hasParser(rv.Type()) {
-		rv = follow(rv)
+// hasEnvFields reports whether compile finds any variable to load in the
+// struct type rt, or in the structs embedded in it. The types are the ones
+// already visited, to stop at the recursive types.
+func (l *Loader) hasEnvFields(rt reflect.Type, types []reflect.Type) bool {
+	for i := 0; i < rt.NumField(); i++ {
+		f := rt.Field(i)
+		tag, hasTag := f.Tag.Lookup("env")
+		if tag == "-" {
+			continue
+		}
+		if hasTag || (l.naming != nil && isExported(f)) {
+			return true
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && ft.Kind() == reflect.Struct && !containsType(types, ft) &&
+			l.hasEnvFields(ft, append(types[:len(types):len(types)], ft)) {
+			return true
+		}
 	}
-	if rv.Kind() == reflect.Map {
-		if err := l.parseAndSetMap(name, rv); err != nil {
-			return fmt.Errorf("cannot parse %s: %w", rv.Type(), err)
+	return false
+}
+
+// loadVar loads the variable of f.
+func (s *loadState) loadVar(f field) error {
+	rv := f.value
+	if !s.hasParser(rv.Type()) {
+		rv = s.follow(rv)
+	}
+	if f.parse == nil {
+		if eu := envUnmarshaler(rv); eu != nil {
+			var errs []error
+			err := eu.UnmarshalEnv(LoadContext{
+				Context: s.ctx,
+				Name:    f.name,
+				Field:   f.path,
+				Tag:     f.tag,
+				Options: f.opts.all,
+				s:       s,
+				secret:  f.opts.secret,
+				errs:    &errs,
+			})
+			if len(errs) > 0 {
+				return &loadError{errs}
+			}
+			return err
 		}
-		return nil
+		if vs := s.variantsOf(rv.Type()); vs != nil {
+			return s.loadVariant(f, rv, vs)
+		}
+	}
+	if rv.Kind() == reflect.Map {
+		return s.parseAndSetMap(f.name, f.path, rv, f.opts)
+	}
+	name, v, ok, err := s.lookup(f)
+	if err != nil {
+		return err
 	}
-	s, ok := os.LookupEnv(name)
 	if !ok {
-		return errors.New("variable missing")
+		if f.rules.conditional() {
+			// Reported by checkRules if it's required.
+			return nil
+		}
+		return ErrMissing
 	}
-	if err := l.parseAndSetValue(s, rv); err != nil {
-		rt := rv.Type()
-		return fmt.Errorf("cannot parse %q as %s: %w", s, rt, err)
+	if err := s.setValue(v.Value, rv, f.parse, f.opts); err != nil {
+		msg := fmt.Sprintf("cannot parse %s as %s", quoteValue(v, f.opts.secret), rv.Type())
+		return &VarError{Name: name, Origin: &v.Origin, Err: valueError(msg, v, f.opts.secret, err)}
+	}
+	s.record(name, f.path, v, f.opts.secret)
+	for _, other := range append([]string{f.name}, f.aliases...) {
+		if other == name {
+			continue
+		}
+		if s.report.aliases == nil {
+			s.report.aliases = make(map[string][]string)
+		}
+		s.report.aliases[name] = append(s.report.aliases[name], other)
 	}
 	return nil
 }
 
-func (l *loader) parseAndSetValue(s string, rv reflect.Value) error {
+// lookup returns the variable of f and the name it was found by, which is
+// either the name of f or the first of its aliases which is set. It's an error
+// if the aliases have values different from the one found.
+func (s *loadState) lookup(f field) (string, Var, bool, error) {
+	name := f.name
+	v, ok, err := s.lookupVar(name)
+	if err != nil {
+		return name, v, ok, err
+	}
+	for _, alias := range f.aliases {
+		av, aok, err := s.lookupVar(alias)
+		if err != nil {
+			return alias, av, aok, err
+		}
+		if !aok {
+			continue
+		}
+		if ok && av.Value != v.Value {
+			err := fmt.Errorf("conflicts with %q, remove the deprecated name", name)
+			return name, v, true, &VarError{Name: alias, Origin: &av.Origin, Err: err}
+		}
+		if !ok {
+			name, v, ok = alias, av, true
+		}
+		msg := fmt.Sprintf("deprecated, use %q instead", f.name)
+		s.warn(Warning{Name: f.name, Alias: alias, Field: f.path, Origin: av.Origin, Message: msg})
+		value := av.Value
+		if isSecret(av, f.opts.secret) {
+			value = redacted
+		}
+		s.event(Event{
+			Kind:    EventAlias,
+			Name:    alias,
+			Field:   f.path,
+			Value:   value,
+			Origin:  av.Origin,
+			Message: msg,
+		})
+	}
+	return name, v, ok, nil
+}
+
+// record adds the provenance of the variable called name to the report and
+// reports it to the event hooks.
+func (s *loadState) record(name, path string, v Var, secret bool) {
+	s.report.Vars = append(s.report.Vars, Provenance{name, path, v.Origin, isSecret(v, secret)})
+	if ref, err := url.Parse(v.Origin.Ref); err == nil && ref.Scheme == "env" && ref.Host != "" {
+		if s.report.refVars == nil {
+			s.report.refVars = make(map[string]string)
+		}
+		s.report.refVars[name] = ref.Host
+	}
+	s.loaded(name, path, v, secret)
+}
+
+func (l *Loader) parseAndSetValue(s string, rv reflect.Value, opts tagOptions) error {
+	return l.setValue(s, rv, l.parser(rv.Type(), opts), opts)
+}
+
+// setValue is the same as parseAndSetValue but it uses the parser f, which may
+// be nil.
+func (l *Loader) setValue(s string, rv reflect.Value, f ParseFunc, opts tagOptions) error {
 	rt := rv.Type()
-	if f := l.parsers[rt]; f != nil {
+	if f != nil {
 		v, err := f(s)
-		if err == nil {
-			rv.Set(reflect.ValueOf(v))
+		if err != nil {
+			return err
 		}
-		return err
+		if v == nil {
+			rv.Set(reflect.Zero(rt))
+			return nil
+		}
+		pv := reflect.ValueOf(v)
+		if !pv.Type().AssignableTo(rt) {
+			return fmt.Errorf("bug: parser returned %s", pv.Type())
+		}
+		rv.Set(pv)
+		return nil
 	}
 	if tu := textUnmarshaler(rv); tu != nil {
 		return tu.UnmarshalText([]byte(s))
 	}
+	if isBytes(rt) {
+		return parseAndSetBytes(s, rv, opts)
+	}
 	if rt.Kind() == reflect.Slice {
-		return l.parseAndSetSlice(s, rv)
+		return l.parseAndSetSlice(s, rv, opts)
 	}
 	return fmt.Errorf("parsing of %v not supported", rt)
 }
@@ -236,58 +926,100 @@ func unescapeSliceField(f string) string {
 }
 
 // parseAndSetSlice parses a comma-separated list of values as a slice.
-func (l *loader) parseAndSetSlice(s string, rv reflect.Value) error {
-	fields, err := tokenizeSliceString(s)
+func (l *Loader) parseAndSetSlice(s string, rv reflect.Value, opts tagOptions) error {
+	fields, err := SplitList(s)
 	if err != nil {
 		return err
 	}
-	for i, f := range fields {
-		fields[i] = unescapeSliceField(f)
-	}
 	nfield := len(fields)
 	sl := reflect.MakeSlice(rv.Type(), nfield, nfield)
+	var errs itemErrors
 	for i, s := range fields {
-		if err := l.parseAndSetValue(s, sl.Index(i)); err != nil {
-			return fmt.Errorf("item #%d: %w", i, err)
+		if err := l.parseAndSetValue(s, sl.Index(i), opts); err != nil {
+			errs = append(errs, fmt.Errorf("item #%d: %w", i, err))
 		}
 	}
+	if len(errs) > 0 {
+		return errs
+	}
 	rv.Set(sl)
 	return nil
 }
 
-func varsPrefixed(prefix string) map[string]string {
-	vars := make(map[string]string)
-	for _, ev := range os.Environ() {
-		spl := strings.SplitN(ev, "=", 2)
-		name, value := spl[0], spl[1]
+// itemErrors are the errors of the items of a list, in the order of the items.
+type itemErrors []error
+
+func (e itemErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, err := range e {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func (e itemErrors) Unwrap() []error {
+	return e
+}
+
+// namesPrefixed returns the sorted names of the variables starting with
+// prefix.
+func (s *loadState) namesPrefixed(prefix string) []string {
+	if snap, ok := s.src.(*snapshot); ok {
+		return snap.prefixed(prefix)
+	}
+	var names []string
+	for _, name := range s.src.Names() {
 		if strings.HasPrefix(name, prefix) {
-			// keys should be unique, as EnvVars are
-			vars[name] = value
+			names = append(names, name)
 		}
 	}
-	return vars
+	sort.Strings(names)
+	return names
 }
 
-func (l *loader) parseAndSetMap(mapName string, rv reflect.Value) error {
+// parseAndSetMap loads the variables prefixed by mapName to the map rv. The
+// errors of all the keys and values are returned as a loadError of the
+// VarErrors of the variables, sorted by their names.
+func (s *loadState) parseAndSetMap(mapName, path string, rv reflect.Value, opts tagOptions) error {
 	rt := rv.Type()
 	kt, vt := rt.Key(), rt.Elem()
 	dstMap := reflect.MakeMap(rt)
 
-	for varName, valStr := range varsPrefixed(mapName) {
+	var errs []error
+	for _, varName := range s.namesPrefixed(mapName) {
+		v, ok, err := s.lookupVar(varName)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !ok {
+			continue
+		}
 		keyStr := varName[len(mapName):]
 		key := reflect.New(kt).Elem() // New creates a pointer
-		if err := l.parseAndSetValue(keyStr, follow(key)); err != nil {
-			msg := "parsing string %q as the key (%s) failed: %w"
-			return fmt.Errorf(msg, keyStr, kt, err)
+		keyErr := s.parseAndSetValue(keyStr, follow(key), opts)
+		if keyErr != nil {
+			msg := "cannot parse %s: parsing string %q as the key (%s) failed: %w"
+			err := fmt.Errorf(msg, rt, keyStr, kt, keyErr)
+			errs = append(errs, &VarError{Name: varName, Origin: &v.Origin, Err: err})
 		}
 
 		val := reflect.New(vt).Elem() // New creates a pointer
-		if err := l.parseAndSetValue(valStr, follow(val)); err != nil {
-			msg := "parsing string %q as the value (%s) failed: %w"
-			return fmt.Errorf(msg, valStr, vt, err)
+		if err := s.parseAndSetValue(v.Value, follow(val), opts); err != nil {
+			msg := "cannot parse %s: parsing string %s as the value (%s) failed"
+			msg = fmt.Sprintf(msg, rt, quoteValue(v, opts.secret), vt)
+			errs = append(errs, &VarError{Name: varName, Origin: &v.Origin, Err: valueError(msg, v, opts.secret, err)})
+			continue
+		}
+		if keyErr != nil {
+			continue
 		}
 
 		dstMap.SetMapIndex(key, val)
+		s.record(varName, path, v, opts.secret)
+	}
+	if len(errs) > 0 {
+		return &loadError{errs}
 	}
 
 	rv.Set(dstMap)
@@ -309,6 +1041,51 @@ func follow(rv reflect.Value) reflect.Value {
 	return rv
 }
 
+// follow is the same as the follow function, but in transactional loads, it
+// doesn't write through the pointers which may be shared with the destination.
+// Instead, it replaces them by pointers to copies of the values, once for each
+// pointer.
+func (s *loadState) follow(rv reflect.Value) reflect.Value {
+	if !s.copyOnWrite {
+		return follow(rv)
+	}
+	for rv.Kind() == reflect.Ptr {
+		if !rv.IsNil() && s.copied[rv.Pointer()] {
+			rv = rv.Elem()
+			continue
+		}
+		rn := reflect.New(rv.Type().Elem())
+		if !rv.IsNil() {
+			rn.Elem().Set(rv.Elem())
+		}
+		rv.Set(rn)
+		if s.copied == nil {
+			s.copied = make(map[uintptr]bool)
+		}
+		s.copied[rn.Pointer()] = true
+		rv = rn.Elem()
+	}
+	return rv
+}
+
+// deref is the derefFunc of the loads. It skips the optional sections with no
+// variables set, setting their pointers to nil.
+func (s *loadState) deref(rv reflect.Value, sec section) (reflect.Value, bool) {
+	if !s.present(sec) {
+		if !rv.IsNil() {
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return rv, false
+	}
+	return s.follow(rv), true
+}
+
+// present tells whether the section is loaded, i.e. it's not optional or some
+// variables with its prefix are set.
+func (s *loadState) present(sec section) bool {
+	return !sec.optional || len(s.namesPrefixed(sec.name)) > 0
+}
+
 func textUnmarshaler(rv reflect.Value) encoding.TextUnmarshaler {
 	if tu, ok := rv.Interface().(encoding.TextUnmarshaler); ok {
 		return tu
@@ -325,8 +1102,11 @@ func textUnmarshaler(rv reflect.Value) encoding.TextUnmarshaler {
 	return nil
 }
 
-func defaultParsers() map[reflect.Type]parseFunc {
-	return map[reflect.Type]parseFunc{
+// builtinParsers are the default parsers of all the loaders.
+var builtinParsers = defaultParsers()
+
+func defaultParsers() map[reflect.Type]ParseFunc {
+	return map[reflect.Type]ParseFunc{
 		reflect.TypeOf(bool(false)):      parseBool,
 		reflect.TypeOf(os.FileMode(0)):   parseFileMode,
 		reflect.TypeOf(float32(0)):       parseFloat32,
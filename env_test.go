@@ -1,6 +1,7 @@
 package env
 
 import (
+	"errors"
 	"fmt"
 	"math"
 	"net/url"
@@ -128,6 +129,19 @@ func TestLoadOK(t *testing.T) {
 	a.Equal(goodConfig, cfg)
 }
 
+func TestLoadInvalidDst(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	want := "env: cannot load environment config: dst must be struct or struct pointer"
+	a.EqualError(Load(nil, ""), want)
+	a.EqualError(New(PartialFill()).Load(nil, ""), want)
+	_, err := Explain(nil, "")
+	a.EqualError(err, want)
+	n := 1
+	a.EqualError(Load(&n, "APP_"), want)
+}
+
 // TestLoadMissingVar will try to remove each variable from goodEnv one by one.
 // We expect to get an error.
 func TestLoadMissingVar(t *testing.T) {
@@ -243,6 +257,23 @@ func TestSliceBad(t *testing.T) {
 	}
 }
 
+func TestSliceBadItems(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("SLICE", "1,x,3,y")
+
+	var c struct {
+		Slice []int `env:"SLICE"`
+	}
+	err := Load(&c, "")
+	a.EqualError(err, `env: cannot load environment config: "SLICE" (env): `+
+		`cannot parse "1,x,3,y" as []int: `+
+		`item #1: strconv.Atoi: parsing "x": invalid syntax; `+
+		`item #3: strconv.Atoi: parsing "y": invalid syntax`)
+	a.Nil(c.Slice)
+}
+
 // TestLoadUnexported tries to load good environment into a structure with an
 // badConfig field. That should fail, but it should not panic.
 func TestLoadUnexported(t *testing.T) {
@@ -569,6 +600,30 @@ func TestMapDurations(t *testing.T) {
 	}
 }
 
+func TestMapBadEntries(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("MAP_c", "x")
+	os.Setenv("MAP_1", "1")
+	os.Setenv("MAP_b", "2")
+	os.Setenv("MAP_3", "y")
+
+	var c struct {
+		Map map[int]int `env:"MAP_"`
+	}
+	err := Load(&c, "")
+	a.EqualError(err, `env: cannot load environment config: `+
+		`"MAP_3" (env): cannot parse map[int]int: parsing string "y" as the value (int) failed: strconv.Atoi: parsing "y": invalid syntax, `+
+		`"MAP_b" (env): cannot parse map[int]int: parsing string "b" as the key (int) failed: strconv.Atoi: parsing "b": invalid syntax, `+
+		`"MAP_c" (env): cannot parse map[int]int: parsing string "c" as the key (int) failed: strconv.Atoi: parsing "c": invalid syntax, `+
+		`"MAP_c" (env): cannot parse map[int]int: parsing string "x" as the value (int) failed: strconv.Atoi: parsing "x": invalid syntax`)
+	a.Nil(c.Map)
+
+	_, err = Get[map[int]int]("MAP_")
+	a.Len(Problems(err), 4)
+}
+
 func TestFileMode(t *testing.T) {
 	a := assert.New(t)
 
@@ -591,3 +646,83 @@ func TestFileMode(t *testing.T) {
 		os.Unsetenv("FILE_MODE")
 	}
 }
+
+// TestLoadTransactional tests that a failed load doesn't modify the
+// destination at all, not even through the pointers it contains.
+func TestLoadTransactional(t *testing.T) {
+	a := assert.New(t)
+
+	falseVar := false
+	intSlice := []int{42}
+	cfg := config{
+		Bool:     &falseVar,
+		Int:      42,
+		IntSlice: &intSlice,
+	}
+	orig := cfg
+
+	oneInvalid := goodEnv.dup()
+	oneInvalid["DURATION"] = "10d"
+	setenv(oneInvalid)
+
+	err := Load(&cfg, examplePrefix)
+	a.Error(err)
+	a.Equal(orig, cfg)
+	a.Same(&falseVar, cfg.Bool)
+	a.False(falseVar)
+	a.Equal([]int{42}, intSlice)
+	a.Nil(cfg.URLPtr)
+
+	// The loaded pointers are not shared with the original values.
+	setenv(goodEnv)
+	a.NoError(Load(&cfg, examplePrefix))
+	a.Equal(goodConfig, cfg)
+	a.NotSame(&falseVar, cfg.Bool)
+	a.False(falseVar)
+	a.Equal([]int{42}, intSlice)
+
+	// A nil struct pointer is allocated only on success.
+	var ptr *config
+	setenv(oneInvalid)
+	a.Error(Load(&ptr, examplePrefix))
+	a.Nil(ptr)
+	setenv(goodEnv)
+	a.NoError(Load(&ptr, examplePrefix))
+	a.Equal(goodConfig, *ptr)
+}
+
+func TestLoadPartialFill(t *testing.T) {
+	a := assert.New(t)
+
+	oneInvalid := goodEnv.dup()
+	oneInvalid["DURATION"] = "10d"
+	setenv(oneInvalid)
+
+	var cfg config
+	err := New(PartialFill()).Load(&cfg, examplePrefix)
+	a.Error(err)
+	a.Equal(goodConfig.Foo, cfg.Foo)
+	a.Equal(goodConfig.Bool, cfg.Bool)
+	a.Zero(cfg.Duration)
+}
+
+func TestErrMissing(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("PORT", "x")
+
+	var c struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	}
+	err := Load(&c, "")
+	a.True(errors.Is(err, ErrMissing))
+	var ve *VarError
+	a.True(errors.As(err, &ve))
+	a.Equal("HOST", ve.Name)
+
+	os.Setenv("HOST", "localhost")
+	err = Load(&c, "")
+	a.False(errors.Is(err, ErrMissing))
+}
@@ -0,0 +1,111 @@
+// Package envtest helps testing the configuration loaded by package env. The
+// tests using its fake sources don't touch the environment of the process,
+// so they may run in parallel:
+//
+//	func TestConfig(t *testing.T) {
+//		t.Parallel()
+//		l := envtest.Loader(map[string]string{"APP_PORT": "80"})
+//		var c config
+//		err := l.Load(&c, "APP_")
+//		envtest.AssertMissing(t, err, "APP_HOST")
+//	}
+package envtest
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Showmax/env"
+)
+
+// Source returns a source holding vars. The values have the origin "test".
+func Source(vars map[string]string) env.Source {
+	return env.Map("test", vars)
+}
+
+// Loader returns a loader reading the variables from vars instead of the
+// environment of the process, modified by opts.
+func Loader(vars map[string]string, opts ...env.Option) *env.Loader {
+	return env.New(append([]env.Option{env.Sources(Source(vars))}, opts...)...)
+}
+
+// Setenv replaces the environment of the process by vars until the end of the
+// test. Like t.Setenv, it cannot be used in parallel tests.
+func Setenv(t testing.TB, vars map[string]string) {
+	t.Helper()
+	for _, ev := range os.Environ() {
+		name, _, _ := strings.Cut(ev, "=")
+		// Let t.Setenv restore the original value.
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	for name, value := range vars {
+		t.Setenv(name, value)
+	}
+}
+
+// VarErrors returns all the VarErrors in the tree of err, e.g. the errors of
+// the variables of a failed load.
+func VarErrors(err error) []*env.VarError {
+	var errs []*env.VarError
+	var walk func(err error)
+	walk = func(err error) {
+		if ve, ok := err.(*env.VarError); ok {
+			errs = append(errs, ve)
+			return
+		}
+		switch err := err.(type) {
+		case interface{ Unwrap() []error }:
+			for _, err := range err.Unwrap() {
+				walk(err)
+			}
+		case interface{ Unwrap() error }:
+			walk(err.Unwrap())
+		}
+	}
+	walk(err)
+	return errs
+}
+
+// AssertMissing checks that err reports the variables called names as
+// missing, see env.ErrMissing. It reports whether the assertion succeeded.
+func AssertMissing(t testing.TB, err error, names ...string) bool {
+	t.Helper()
+	ok := true
+	for _, name := range names {
+		ve := varError(err, name)
+		if ve == nil || !errors.Is(ve, env.ErrMissing) {
+			t.Errorf("%q is not reported as missing by: %v", name, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// AssertInvalid checks that err reports the variables called names as
+// present but invalid, e.g. not parsable. It reports whether the assertion
+// succeeded.
+func AssertInvalid(t testing.TB, err error, names ...string) bool {
+	t.Helper()
+	ok := true
+	for _, name := range names {
+		ve := varError(err, name)
+		if ve == nil || errors.Is(ve, env.ErrMissing) {
+			t.Errorf("%q is not reported as invalid by: %v", name, err)
+			ok = false
+		}
+	}
+	return ok
+}
+
+// varError returns the first VarError of the variable called name in err.
+func varError(err error, name string) *env.VarError {
+	for _, ve := range VarErrors(err) {
+		if ve.Name == name {
+			return ve
+		}
+	}
+	return nil
+}
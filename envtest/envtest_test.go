@@ -0,0 +1,84 @@
+package envtest
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/Showmax/env"
+)
+
+// fakeT records the failures of the assertions.
+type fakeT struct {
+	testing.TB
+	errs []string
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.errs = append(t.errs, fmt.Sprintf(format, args...))
+}
+
+type config struct {
+	Host string `env:"HOST" desc:"Host to connect to."`
+	Port int    `env:"PORT"`
+	User string `env:"USER"`
+}
+
+func TestLoader(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	l := Loader(map[string]string{"APP_PORT": "x", "APP_USER": "joe"}, env.PartialFill())
+	var c config
+	err := l.Load(&c, "APP_")
+	a.Error(err)
+	a.Equal("joe", c.User, "options must apply")
+
+	a.True(AssertMissing(t, err, "APP_HOST"))
+	a.True(AssertInvalid(t, err, "APP_PORT"))
+	a.Len(VarErrors(err), 2)
+
+	ft := &fakeT{TB: t}
+	a.False(AssertMissing(ft, err, "APP_PORT", "APP_USER"))
+	a.False(AssertInvalid(ft, err, "APP_HOST"))
+	a.Len(ft.errs, 3)
+	a.Contains(ft.errs[0], `"APP_PORT" is not reported as missing by: env: cannot load environment config`)
+	a.False(AssertMissing(ft, nil, "APP_HOST"))
+}
+
+func TestSetenv(t *testing.T) {
+	a := assert.New(t)
+
+	os.Setenv("ENVTEST_KEPT", "kept")
+	defer os.Unsetenv("ENVTEST_KEPT")
+	t.Run("scoped", func(t *testing.T) {
+		Setenv(t, map[string]string{"APP_HOST": "localhost"})
+		a.Equal([]string{"APP_HOST=localhost"}, os.Environ())
+	})
+	a.Equal("kept", os.Getenv("ENVTEST_KEPT"))
+	_, ok := os.LookupEnv("APP_HOST")
+	a.False(ok)
+}
+
+func TestGolden(t *testing.T) {
+	t.Parallel()
+	a := assert.New(t)
+
+	l := env.New()
+	a.True(GoldenUsage(t, l, (*config)(nil), "APP_", filepath.Join("testdata", "usage.golden")))
+	a.True(GoldenDescribe(t, l, (*config)(nil), "APP_", filepath.Join("testdata", "describe.golden")))
+
+	if *update {
+		return
+	}
+	ft := &fakeT{TB: t}
+	a.False(Golden(ft, filepath.Join("testdata", "usage.golden"), []byte("different\n")))
+	a.False(Golden(ft, filepath.Join("testdata", "missing.golden"), nil))
+	a.Len(ft.errs, 2)
+	a.Contains(ft.errs[0], "differs from the golden file testdata/usage.golden")
+}
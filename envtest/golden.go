@@ -0,0 +1,66 @@
+package envtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Showmax/env"
+)
+
+var update = flag.Bool("envtest.update", false, "update the golden files of envtest")
+
+// Golden checks that got equals the content of the golden file at path. When
+// the tests are run with the -envtest.update flag, the file is written
+// instead, creating the directories as needed.
+func Golden(t testing.TB, path string, got []byte) bool {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return true
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Errorf("%v (run the tests with -envtest.update to create it)", err)
+		return false
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("got:\n%s\nwhich differs from the golden file %s (run the tests with -envtest.update to update it)", got, path)
+		return false
+	}
+	return true
+}
+
+// GoldenUsage checks the output of l.Usage(dst, prefix) by the golden file at
+// path, see Golden.
+func GoldenUsage(t testing.TB, l *env.Loader, dst interface{}, prefix, path string) bool {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := l.Usage(&buf, dst, prefix); err != nil {
+		t.Fatal(err)
+	}
+	return Golden(t, path, buf.Bytes())
+}
+
+// GoldenDescribe checks the output of l.Describe(dst, prefix), encoded as
+// indented JSON, by the golden file at path, see Golden.
+func GoldenDescribe(t testing.TB, l *env.Loader, dst interface{}, prefix, path string) bool {
+	t.Helper()
+	vars, err := l.Describe(dst, prefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := json.MarshalIndent(vars, "", "\t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return Golden(t, path, append(b, '\n'))
+}
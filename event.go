@@ -0,0 +1,175 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// EventKind is the kind of an Event.
+type EventKind int
+
+const (
+	// EventLoaded is a variable loaded from a source.
+	EventLoaded EventKind = iota
+
+	// EventDefault is a variable loaded from a source of defaults, see
+	// Defaults.
+	EventDefault
+
+	// EventRedacted is a secret variable loaded from a source. Its value
+	// is redacted, see the secret tag option and ResolveScheme.
+	EventRedacted
+
+	// EventAlias is a use of a deprecated name of a variable, see the alias
+	// tag option.
+	EventAlias
+
+	// EventUnknown is a variable with the prefix of the load which isn't
+	// used by any field, e.g. a misspelled one. The unknown variables are
+	// reported only by the loads with a non-empty prefix.
+	EventUnknown
+
+	// EventInactive is a variable of a variant which isn't selected by the
+	// discriminator, see RegisterVariants.
+	EventInactive
+)
+
+var eventKinds = []string{"loaded", "default", "redacted", "alias", "unknown", "inactive"}
+
+func (k EventKind) String() string {
+	if int(k) < len(eventKinds) {
+		return eventKinds[k]
+	}
+	return fmt.Sprintf("EventKind(%d)", int(k))
+}
+
+// Event describes a decision made by a load, see OnEvent.
+type Event struct {
+	Kind EventKind
+
+	// Name is the name of the variable, including the prefix. For
+	// EventAlias, it's the deprecated name.
+	Name string
+
+	// Field is the path to the struct field, e.g. "DB.User". It's empty for
+	// EventUnknown.
+	Field string
+
+	// Value is the value of the variable, or "<redacted>" if it's secret.
+	Value string
+
+	Origin Origin
+
+	// Message describes the event.
+	Message string
+}
+
+func (e Event) String() string {
+	return fmt.Sprintf("%s %q (%s): %s", e.Kind, e.Name, e.Origin, e.Message)
+}
+
+// OnEvent makes the loader call f with each event of the loads, e.g. to log
+// the configuration at startup, see also SlogEvents. The events are reported
+// as they happen, so a failed load may report some events too. The option
+// may be given more than once to add more hooks.
+func OnEvent(f func(e Event)) Option {
+	return func(l *Loader) {
+		l.onEvent = append(l.onEvent, f)
+	}
+}
+
+func (s *loadState) event(e Event) {
+	for _, f := range s.onEvent {
+		f(e)
+	}
+}
+
+// loaded reports the variable called name loaded to the field at path.
+func (s *loadState) loaded(name, path string, v Var, secret bool) {
+	if len(s.onEvent) == 0 {
+		return
+	}
+	e := Event{Kind: EventLoaded, Name: name, Field: path, Value: v.Value, Origin: v.Origin}
+	switch {
+	case isSecret(v, secret):
+		e.Kind, e.Value = EventRedacted, redacted
+		e.Message = "secret value loaded"
+	case v.Origin.Default:
+		e.Kind, e.Message = EventDefault, "default value applied"
+	default:
+		e.Message = "value loaded"
+	}
+	s.event(e)
+}
+
+// reportsUnused tells whether the unused variables are reported, see unused.
+func (s *loadState) reportsUnused() bool {
+	return len(s.onEvent) > 0 || s.strict
+}
+
+// unused reports the variable called name which is set but not used by the
+// field at path, if any, to the event hooks and, in strict mode, to the
+// warning hook.
+func (s *loadState) unused(kind EventKind, name, path, msg string) {
+	v, _ := s.src.Lookup(name)
+	if s.strict {
+		s.warn(Warning{Name: name, Field: path, Origin: v.Origin, Message: msg})
+	}
+	s.event(Event{
+		Kind:    kind,
+		Name:    name,
+		Field:   path,
+		Value:   redacted,
+		Origin:  v.Origin,
+		Message: msg,
+	})
+}
+
+// reportUnknown reports the variables with the prefix which are not used by
+// any field of the struct rt.
+func (s *loadState) reportUnknown(rt reflect.Type, prefix string) {
+	if !s.reportsUnused() || prefix == "" {
+		return
+	}
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return
+	}
+	known := make(map[string]bool)
+	var prefixes []string
+	for _, pf := range s.plan(rt, prefix) {
+		if pf.err != nil {
+			continue
+		}
+		known[pf.name] = true
+		for _, alias := range pf.aliases {
+			known[alias] = true
+		}
+		ft := pf.typeIn(rt)
+		if !s.hasParser(ft) {
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+		}
+		if pf.parse == nil && (isMap(ft) || isEnvUnmarshaler(ft) || s.variantsOf(ft) != nil) {
+			prefixes = append(prefixes, pf.name)
+		}
+	}
+	for _, name := range s.namesPrefixed(prefix) {
+		if !known[name] && !hasAnyPrefix(name, prefixes...) {
+			s.unused(EventUnknown, name, "", "unknown variable")
+		}
+	}
+}
+
+func hasAnyPrefix(s string, prefixes ...string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,101 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEvents(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Host     string            `env:"HOST,alias=SERVER"`
+		Port     int               `env:"PORT"`
+		Password string            `env:"PASSWORD,secret"`
+		Token    string            `env:"TOKEN"`
+		Labels   map[string]string `env:"LABEL_"`
+	}
+
+	var events []string
+	l := New(
+		Sources(OSEnv(), Defaults(map[string]string{"APP_PORT": "80"})),
+		DefaultResolvers(),
+		OnEvent(func(e Event) {
+			events = append(events, e.Kind.String()+" "+e.Name+" "+e.Field+" "+e.Value)
+		}),
+	)
+
+	os.Clearenv()
+	os.Setenv("APP_SERVER", "localhost")
+	os.Setenv("APP_PASSWORD", "hunter2")
+	os.Setenv("APP_TOKEN", "env://CI_TOKEN")
+	os.Setenv("CI_TOKEN", "t0k3n")
+	os.Setenv("APP_LABEL_TEAM", "core")
+	os.Setenv("APP_PROT", "8080")
+
+	var c cfg
+	a.NoError(l.Load(&c, "APP_"))
+	a.Equal([]string{
+		"alias APP_SERVER Host localhost",
+		"loaded APP_SERVER Host localhost",
+		"default APP_PORT Port 80",
+		"redacted APP_PASSWORD Password <redacted>",
+		"redacted APP_TOKEN Token <redacted>",
+		"loaded APP_LABEL_TEAM Labels core",
+		"unknown APP_PROT  <redacted>",
+	}, events)
+
+	// The unknown variables are not reported without a prefix.
+	events = nil
+	var p struct {
+		Path string `env:"CI_TOKEN"`
+	}
+	a.NoError(l.Load(&p, ""))
+	a.Equal([]string{"loaded CI_TOKEN Path t0k3n"}, events)
+}
+
+func TestEventSecretError(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("PIN", "a")
+
+	var c struct {
+		PIN int `env:"PIN,secret"`
+	}
+	err := Load(&c, "")
+	a.EqualError(err, `env: cannot load environment config: "PIN" (env): cannot parse <redacted> as int`)
+	a.True(errors.Is(err, strconv.ErrSyntax))
+}
+
+func TestSlogEvents(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	h := slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})
+	l := New(SlogEvents(h))
+
+	os.Clearenv()
+	os.Setenv("APP_PORT", "80")
+	os.Setenv("APP_PROT", "8080")
+
+	var c struct {
+		Port int `env:"PORT"`
+	}
+	a.NoError(l.Load(&c, "APP_"))
+	log := regexp.MustCompile(`time=\S+ `).ReplaceAllString(buf.String(), "")
+	a.Equal(`level=WARN msg="env: unknown variable" event=unknown name=APP_PROT value=<redacted> origin=env`+"\n", log)
+
+	buf.Reset()
+	h = slog.NewTextHandler(&buf, nil)
+	a.NoError(New(SlogEvents(h)).Load(&c, "APP_"))
+	log = regexp.MustCompile(`time=\S+ `).ReplaceAllString(buf.String(), "")
+	a.Contains(log, `level=INFO msg="env: value loaded" event=loaded name=APP_PORT field=Port value=80 origin=env`+"\n")
+}
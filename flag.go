@@ -0,0 +1,118 @@
+package env
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// LoadFlags loads dst like Load, but each variable can also be set by
+// a command-line flag. The flags are defined in fs and args are parsed by it,
+// so the flag values take precedence over all the sources of the loader.
+//
+// The flag names are derived from the variable names without the prefix, e.g.
+// PREFIX_DB_USER becomes -db-user. A different name can be given by the flag
+// tag, and `flag:"-"` defines no flag for the field. Maps, EnvUnmarshalers
+// and variants have no flags. The flag usage is taken from the desc tag.
+//
+// The flag values are parsed in the same way as the values of variables and
+// all the errors are reported together by the returned error. The errors of
+// fs.Parse (including flag.ErrHelp) are returned as they are.
+func (l *Loader) LoadFlags(fs *flag.FlagSet, args []string, dst interface{}, prefix string) error {
+	flags, err := l.defineFlags(fs, dst, prefix)
+	if err != nil {
+		return err
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	set := make(map[string]string)
+	fs.Visit(func(f *flag.Flag) {
+		if fv, ok := flags[f.Name]; ok {
+			set[fv.name] = fv.value
+		}
+	})
+	s := l.newLoadState(context.Background())
+	s.src = Layered(newMapSource(set, Origin{Source: "flag"}), s.src)
+	if errs := s.load(dst, prefix); len(errs) > 0 {
+		return &loadError{errs}
+	}
+	return nil
+}
+
+// LoadFlags loads dst using a Loader with no options, see Loader.LoadFlags.
+func LoadFlags(fs *flag.FlagSet, args []string, dst interface{}, prefix string) error {
+	return New().LoadFlags(fs, args, dst, prefix)
+}
+
+// flagValue is a flag.Value which just stores the value of the flag so that
+// it's parsed together with the variables.
+type flagValue struct {
+	// name is the name of the variable set by the flag.
+	name   string
+	value  string
+	isBool bool
+}
+
+func (fv *flagValue) String() string {
+	if fv == nil {
+		return ""
+	}
+	return fv.value
+}
+
+func (fv *flagValue) Set(s string) error {
+	fv.value = s
+	return nil
+}
+
+func (fv *flagValue) IsBoolFlag() bool {
+	return fv.isBool
+}
+
+// defineFlags defines a flag in fs for every variable dst would be loaded from.
+// It returns the defined flags by their names.
+func (l *Loader) defineFlags(fs *flag.FlagSet, dst interface{}, prefix string) (map[string]*flagValue, error) {
+	rt := reflect.TypeOf(dst)
+	if rt == nil {
+		return nil, &loadError{[]error{errInvalidDst}}
+	}
+	flags := make(map[string]*flagValue)
+	errs := l.walk(reflect.New(rt), prefix, allocate, func(f field) error {
+		rt := f.value.Type()
+		if isMap(rt) || isEnvUnmarshaler(rt) || l.variantsOf(rt) != nil {
+			return nil
+		}
+		name, ok := f.tag.Lookup("flag")
+		if name == "-" {
+			return nil
+		}
+		if !ok {
+			name = flagName(strings.TrimPrefix(f.name, prefix))
+		}
+		if fs.Lookup(name) != nil {
+			return fmt.Errorf("flag -%s defined twice", name)
+		}
+		for rt.Kind() == reflect.Ptr {
+			rt = rt.Elem()
+		}
+		fv := &flagValue{name: f.name, isBool: rt.Kind() == reflect.Bool}
+		usage := describeVar(f.tag.Get("desc"), l.enumChoices(f.value.Type()))
+		fs.Var(fv, name, usage)
+		flags[name] = fv
+		return nil
+	})
+	if len(errs) > 0 {
+		return nil, &loadError{errs}
+	}
+	return flags, nil
+}
+
+// flagName converts the name of a variable to the name of a flag, e.g.
+// DB_USER to db-user.
+func flagName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
@@ -0,0 +1,89 @@
+package env
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type flagConfig struct {
+	Addr    string            `env:"ADDR" desc:"Address to listen on."`
+	Verbose bool              `env:"VERBOSE"`
+	Workers *int              `env:"WORKERS" flag:"j"`
+	DB      Foo               `env:"DB_"`
+	Secret  string            `env:"SECRET" flag:"-"`
+	Labels  map[string]string `env:"LABEL_"`
+}
+
+func TestLoadFlags(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_ADDR", ":80")
+	os.Setenv("APP_WORKERS", "4")
+	os.Setenv("APP_DB_FOO", "env")
+	os.Setenv("APP_SECRET", "hunter2")
+
+	l := New(Sources(OSEnv(), Defaults(map[string]string{
+		"APP_VERBOSE": "false",
+	})))
+
+	var c flagConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	err := l.LoadFlags(fs, []string{"-addr", ":8080", "-verbose", "-db-foo=flag", "arg"}, &c, "APP_")
+	a.NoError(err)
+
+	workers := 4
+	a.Equal(flagConfig{
+		Addr:    ":8080",
+		Verbose: true,
+		Workers: &workers,
+		DB:      Foo{"flag"},
+		Secret:  "hunter2",
+		Labels:  map[string]string{},
+	}, c)
+	a.Equal([]string{"arg"}, fs.Args())
+	a.Nil(fs.Lookup("secret"))
+	a.Nil(fs.Lookup("labels"))
+	a.Equal("Address to listen on.", fs.Lookup("addr").Usage)
+
+	// Defaults are still used when neither the flag nor the variable is
+	// set.
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	a.NoError(l.LoadFlags(fs, []string{"-j", "8"}, &c, "APP_"))
+	a.False(c.Verbose)
+	a.Equal(8, *c.Workers)
+}
+
+func TestLoadFlagsErrors(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_ADDR", ":80")
+	os.Setenv("APP_WORKERS", "x")
+	os.Setenv("APP_SECRET", "hunter2")
+
+	var c flagConfig
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	err := LoadFlags(fs, []string{"-verbose=maybe"}, &c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: `+
+		`"APP_VERBOSE" (flag): cannot parse "maybe" as bool: `+
+		`strconv.ParseBool: parsing "maybe": invalid syntax, `+
+		`"APP_WORKERS" (env): cannot parse "x" as int: `+
+		`strconv.Atoi: parsing "x": invalid syntax, `+
+		`"APP_DB_FOO": variable missing`)
+
+	var out bytes.Buffer
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(&out)
+	err = LoadFlags(fs, []string{"-help"}, &c, "APP_")
+	a.Equal(flag.ErrHelp, err)
+	a.Contains(out.String(), "Address to listen on.")
+
+	fs = flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.String("addr", "", "")
+	a.Error(LoadFlags(fs, nil, &c, "APP_"), "duplicate flag should fail")
+}
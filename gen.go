@@ -0,0 +1,128 @@
+package env
+
+import (
+	"fmt"
+)
+
+// Gen is the state of a loader generated by the envgen command, which loads
+// a struct without reflection (see cmd/envgen). It's not meant to be used
+// directly; the generated loaders use it by the GenVar, GenSlice and GenMap
+// functions.
+type Gen struct {
+	src  *snapshot
+	errs []error
+}
+
+// NewGen returns the state of a generated loader reading from a snapshot of
+// src, or of the environment if src is nil.
+func NewGen(src Source) *Gen {
+	if src == nil {
+		src = OSEnv()
+	}
+	return &Gen{src: takeSnapshot(src)}
+}
+
+// Err returns the errors of the load, the same as the error returned by Load.
+func (g *Gen) Err() error {
+	if len(g.errs) > 0 {
+		return &loadError{g.errs}
+	}
+	return nil
+}
+
+// GenVar loads the variable called name to dst using parse. The typ is the name
+// of the type of dst, used in the error.
+func GenVar[T any](g *Gen, name, typ string, dst *T, parse func(s string) (T, error)) {
+	v, ok := g.src.Lookup(name)
+	if !ok {
+		g.errs = append(g.errs, &VarError{Name: name, Err: ErrMissing})
+		return
+	}
+	val, err := parse(v.Value)
+	if err != nil {
+		g.parseError(name, typ, v, err)
+		return
+	}
+	*dst = val
+}
+
+// GenSlice loads the comma-separated list of values of the variable called name
+// to dst, parsing the items by parse.
+func GenSlice[S ~[]T, T any](g *Gen, name, typ string, dst *S, parse func(s string) (T, error)) {
+	v, ok := g.src.Lookup(name)
+	if !ok {
+		g.errs = append(g.errs, &VarError{Name: name, Err: ErrMissing})
+		return
+	}
+	fields, err := SplitList(v.Value)
+	if err != nil {
+		g.parseError(name, typ, v, err)
+		return
+	}
+	sl := make(S, len(fields))
+	var errs itemErrors
+	for i, f := range fields {
+		if sl[i], err = parse(f); err != nil {
+			errs = append(errs, fmt.Errorf("item #%d: %w", i, err))
+		}
+	}
+	if len(errs) > 0 {
+		g.parseError(name, typ, v, errs)
+		return
+	}
+	*dst = sl
+}
+
+// GenMap loads the variables prefixed by name to dst. The keys are the rest of
+// their names parsed by key and the values are parsed by val. The keyTyp and
+// valTyp are the names of the types of the keys and values.
+func GenMap[M ~map[K]V, K comparable, V any](g *Gen, name, typ, keyTyp, valTyp string, dst *M, key func(s string) (K, error), val func(s string) (V, error)) {
+	m := make(M)
+	valid := true
+	for _, varName := range g.src.prefixed(name) {
+		v, ok := g.src.Lookup(varName)
+		if !ok {
+			continue
+		}
+		keyStr := varName[len(name):]
+		k, keyErr := key(keyStr)
+		if keyErr != nil {
+			msg := "cannot parse %s: parsing string %q as the key (%s) failed: %w"
+			err := fmt.Errorf(msg, typ, keyStr, keyTyp, keyErr)
+			g.errs = append(g.errs, &VarError{Name: varName, Origin: &v.Origin, Err: err})
+			valid = false
+		}
+		e, err := val(v.Value)
+		if err != nil {
+			msg := "cannot parse %s: parsing string %s as the value (%s) failed"
+			msg = fmt.Sprintf(msg, typ, quoteValue(v, false), valTyp)
+			g.errs = append(g.errs, &VarError{Name: varName, Origin: &v.Origin, Err: valueError(msg, v, false, err)})
+			valid = false
+			continue
+		}
+		if keyErr == nil {
+			m[k] = e
+		}
+	}
+	if valid {
+		*dst = m
+	}
+}
+
+func (g *Gen) parseError(name, typ string, v Var, err error) {
+	msg := fmt.Sprintf("cannot parse %s as %s", quoteValue(v, false), typ)
+	g.errs = append(g.errs, &VarError{Name: name, Origin: &v.Origin, Err: valueError(msg, v, false, err)})
+}
+
+// SplitList splits a comma-separated list of values the same way as the
+// values of slices are split by Load.
+func SplitList(s string) ([]string, error) {
+	fields, err := tokenizeSliceString(s)
+	if err != nil {
+		return nil, err
+	}
+	for i, f := range fields {
+		fields[i] = unescapeSliceField(f)
+	}
+	return fields, nil
+}
@@ -0,0 +1,53 @@
+package env
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGen(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Port  int            `env:"PORT"`
+		Ports []int          `env:"PORTS"`
+		Limit map[string]int `env:"LIMIT_"`
+		Host  string         `env:"HOST"`
+	}
+
+	src := Map("test", map[string]string{
+		"PORT":    "x",
+		"PORTS":   "1,x",
+		"LIMIT_a": "1",
+		"LIMIT_b": "x",
+	})
+	var want cfg
+	wantErr := New(Sources(src)).Load(&want, "")
+
+	var got cfg
+	g := NewGen(src)
+	GenVar(g, "PORT", "int", &got.Port, strconv.Atoi)
+	GenSlice(g, "PORTS", "[]int", &got.Ports, strconv.Atoi)
+	GenMap(g, "LIMIT_", "map[string]int", "string", "int", &got.Limit, func(s string) (string, error) {
+		return s, nil
+	}, strconv.Atoi)
+	GenVar(g, "HOST", "string", &got.Host, func(s string) (string, error) {
+		return s, nil
+	})
+	a.Equal(wantErr.Error(), g.Err().Error())
+
+	a.Nil(NewGen(Map("test", nil)).Err())
+}
+
+func TestSplitList(t *testing.T) {
+	a := assert.New(t)
+
+	fields, err := SplitList(` a, "b,c" ,d\,e`)
+	a.NoError(err)
+	a.Equal([]string{"a", "b,c", "d,e"}, fields)
+
+	_, err = SplitList(`a,"b`)
+	a.EqualError(err, "unbalanced quotes")
+}
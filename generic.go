@@ -0,0 +1,81 @@
+package env
+
+import (
+	"context"
+	"reflect"
+)
+
+// RegisterParser registers f as the parser of all instances of T in r, which
+// is either a Loader or a Registry, e.g. DefaultRegistry. Unlike AddParser,
+// the type of the parsed values is checked by the compiler.
+func RegisterParser[T any](r ParserRegistry, f func(s string) (T, error)) {
+	r.AddParser(typeOf[T](), func(s string) (interface{}, error) {
+		v, err := f(s)
+		if err != nil {
+			return nil, err
+		}
+		return v, nil
+	})
+}
+
+// Get parses the variable called name as T, using a Loader with no options.
+// The same rules as for struct fields apply, e.g. slices are parsed as
+// comma-separated lists and maps are loaded from all the variables prefixed
+// by name. A missing variable is an error.
+func Get[T any](name string) (T, error) {
+	return GetWith[T](New(), name)
+}
+
+// GetWith is the same as Get but it uses the parsers, sources and resolvers of
+// l.
+func GetWith[T any](l *Loader, name string) (T, error) {
+	var v T
+	s := l.newLoadState(context.Background())
+	s.src = takeSnapshot(s.src)
+	if len(s.resolvers) > 0 {
+		defer s.resolving()()
+	}
+	rv := reflect.ValueOf(&v).Elem()
+	f := field{name: name, value: rv, parse: l.fieldParser(rv.Type(), tagOptions{})}
+	if err := s.loadVar(f); err != nil {
+		if le, ok := err.(*loadError); ok {
+			return v, le
+		}
+		return v, &loadError{[]error{asVarError(name, err)}}
+	}
+	return v, nil
+}
+
+// Lookup is the same as Get but a missing variable isn't an error. Instead,
+// the zero value of T and false are returned. The returned bool is true
+// whenever the variable is present, even if it cannot be parsed.
+func Lookup[T any](name string) (T, bool, error) {
+	return LookupWith[T](New(), name)
+}
+
+// LookupWith is the same as Lookup but it uses the parsers, sources and
+// resolvers of l.
+func LookupWith[T any](l *Loader, name string) (T, bool, error) {
+	var v T
+	if !isMap(typeOf[T]()) {
+		if _, ok := l.src.Lookup(name); !ok {
+			return v, false, nil
+		}
+	}
+	v, err := GetWith[T](l, name)
+	return v, true, err
+}
+
+// typeOf returns the reflect.Type of T, which works for interfaces as well.
+func typeOf[T any]() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// isMap reports whether rt is a map or a (multiple) pointer to a map. Maps are
+// loaded from all the variables with a common prefix.
+func isMap(rt reflect.Type) bool {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	return rt.Kind() == reflect.Map
+}
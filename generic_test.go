@@ -0,0 +1,157 @@
+package env
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegisterParser(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		IP  net.IP   `env:"IP"`
+		IPs []net.IP `env:"IPS"`
+	}
+
+	l := New()
+	RegisterParser(l, func(s string) (net.IP, error) {
+		ip := net.ParseIP(s)
+		if ip == nil {
+			return nil, errors.New("invalid IP address")
+		}
+		return ip, nil
+	})
+
+	os.Clearenv()
+	os.Setenv("IP", "10.0.0.1")
+	os.Setenv("IPS", "10.0.0.2, 10.0.0.3")
+
+	var c cfg
+	a.NoError(l.Load(&c, ""))
+	a.Equal("10.0.0.1", c.IP.String())
+	a.Len(c.IPs, 2)
+	a.Equal("10.0.0.3", c.IPs[1].String())
+
+	os.Setenv("IP", "10.0.0")
+	a.Error(l.Load(&c, ""))
+}
+
+func TestAddParserWrongType(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Int int `env:"INT"`
+	}
+
+	l := New()
+	l.AddParser(typeOf[int](), func(s string) (interface{}, error) {
+		return s, nil
+	})
+
+	os.Clearenv()
+	os.Setenv("INT", "1")
+
+	var c cfg
+	a.NotPanics(func() {
+		a.Error(l.Load(&c, ""))
+	})
+}
+
+func TestGet(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("TIMEOUT", "5s")
+	os.Setenv("HOSTS", "a,b")
+	os.Setenv("PORT_http", "80")
+	os.Setenv("BAD", "x")
+
+	timeout, err := Get[time.Duration]("TIMEOUT")
+	a.NoError(err)
+	a.Equal(5*time.Second, timeout)
+
+	hosts, err := Get[*[]string]("HOSTS")
+	a.NoError(err)
+	a.Equal([]string{"a", "b"}, *hosts)
+
+	ports, err := Get[map[string]int]("PORT_")
+	a.NoError(err)
+	a.Equal(map[string]int{"http": 80}, ports)
+
+	_, err = Get[int]("MISSING")
+	a.EqualError(err, `env: cannot load environment config: "MISSING": variable missing`)
+
+	_, err = Get[int]("BAD")
+	a.Error(err)
+
+	l := New()
+	RegisterParser(l, func(s string) (int, error) {
+		return len(s), nil
+	})
+	n, err := GetWith[int](l, "HOSTS")
+	a.NoError(err)
+	a.Equal(3, n)
+}
+
+func TestLookup(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("NAME", "foo")
+	os.Setenv("BAD", "x")
+
+	name, ok, err := Lookup[string]("NAME")
+	a.NoError(err)
+	a.True(ok)
+	a.Equal("foo", name)
+
+	n, ok, err := Lookup[int]("MISSING")
+	a.NoError(err)
+	a.False(ok)
+	a.Zero(n)
+
+	_, ok, err = Lookup[int]("BAD")
+	a.Error(err)
+	a.True(ok)
+
+	upper, ok, err := LookupWith[string](upperLoader(), "NAME")
+	a.NoError(err)
+	a.True(ok)
+	a.Equal("FOO", upper)
+}
+
+func TestGetResolve(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("P_MODE", "production")
+	os.Setenv("P_REF", "env://P_MODE")
+	os.Setenv("P_PORTS_http", "env://P_MISSING")
+
+	l := New(DefaultResolvers())
+	mode, err := GetWith[string](l, "P_REF")
+	a.NoError(err)
+	a.Equal("production", mode)
+
+	mode, ok, err := LookupWith[string](l, "P_REF")
+	a.NoError(err)
+	a.True(ok)
+	a.Equal("production", mode)
+
+	_, err = GetWith[map[string]int](l, "P_PORTS_")
+	a.EqualError(err, `env: cannot load environment config: "P_PORTS_http" (env): cannot resolve "env://P_MISSING": variable "P_MISSING" missing`)
+}
+
+func upperLoader() *Loader {
+	l := New()
+	RegisterParser(l, func(s string) (string, error) {
+		return strings.ToUpper(s), nil
+	})
+	return l
+}
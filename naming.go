@@ -0,0 +1,44 @@
+package env
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// Naming makes the loader load all exported fields, not just the ones with an
+// env tag. The names of the variables of untagged fields (or fields with an
+// empty name in the tag) are derived from the field names by f, e.g. SnakeCase.
+// Explicit names in tags are used as they are.
+//
+// Additionally, the "_" separator is appended to the names of nested structs
+// and maps unless they already end with it, so `env:"DB"` is the same as
+// `env:"DB_"`. Fields tagged with `env:"-"` are skipped.
+func Naming(f func(field string) string) Option {
+	return func(l *Loader) {
+		l.naming = f
+		l.plans = new(sync.Map)
+	}
+}
+
+// SnakeCase converts a Go identifier to SCREAMING_SNAKE_CASE. Initialisms are
+// kept together, e.g. HTTPPort becomes HTTP_PORT and UserID becomes USER_ID.
+func SnakeCase(name string) string {
+	runes := []rune(name)
+	var sb strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			// Word boundary is either a lower case letter or a digit
+			// followed by an upper case letter (fooBar), or the last
+			// letter of an initialism (HTTPPort).
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && nextLower) {
+				sb.WriteByte('_')
+			}
+		}
+		sb.WriteRune(unicode.ToUpper(r))
+	}
+	return sb.String()
+}
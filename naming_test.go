@@ -0,0 +1,76 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnakeCase(t *testing.T) {
+	a := assert.New(t)
+
+	samples := map[string]string{
+		"Port":       "PORT",
+		"HTTPPort":   "HTTP_PORT",
+		"UserID":     "USER_ID",
+		"DBUser":     "DB_USER",
+		"MaxConns":   "MAX_CONNS",
+		"S3Bucket":   "S3_BUCKET",
+		"Retry2x":    "RETRY2X",
+		"Snake_Case": "SNAKE_CASE",
+		"URL":        "URL",
+		"A":          "A",
+	}
+	for in, ref := range samples {
+		a.Equal(ref, SnakeCase(in), "converting %q", in)
+	}
+}
+
+func TestNaming(t *testing.T) {
+	a := assert.New(t)
+
+	type db struct {
+		User string
+		Pass string `env:"PASSWORD"`
+	}
+	type Shared struct {
+		LogLevel string
+	}
+	type cfg struct {
+		Shared
+		HTTPPort int
+		DB       db
+		Replica  db                `env:"REPLICA_"`
+		Labels   map[string]string `env:"LABEL"`
+		Skipped  string            `env:"-"`
+		internal string
+	}
+
+	os.Clearenv()
+	os.Setenv("APP_LOG_LEVEL", "debug")
+	os.Setenv("APP_HTTP_PORT", "8080")
+	os.Setenv("APP_DB_USER", "joe")
+	os.Setenv("APP_DB_PASSWORD", "secret")
+	os.Setenv("APP_REPLICA_USER", "jane")
+	os.Setenv("APP_REPLICA_PASSWORD", "hunter2")
+	os.Setenv("APP_LABEL_team", "core")
+	os.Setenv("APP_SKIPPED", "foo")
+
+	var c cfg
+	err := New(Naming(SnakeCase)).Load(&c, "APP_")
+	a.NoError(err)
+	a.Equal(cfg{
+		Shared:   Shared{"debug"},
+		HTTPPort: 8080,
+		DB:       db{"joe", "secret"},
+		Replica:  db{"jane", "hunter2"},
+		Labels:   map[string]string{"team": "core"},
+	}, c)
+
+	// Untagged fields are ignored by default.
+	var c2 cfg
+	a.NoError(Load(&c2, "APP_"))
+	a.Zero(c2.HTTPPort)
+	a.Equal(db{"", "hunter2"}, c2.Replica)
+}
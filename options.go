@@ -0,0 +1,176 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Option modifies the behavior of a Loader, see New.
+type Option func(l *Loader)
+
+// AutoIntBase makes the loader detect the base of all integers from their
+// prefix: 0x (or 0X) for hexadecimal, 0o (or 0O) or just 0 for octal and 0b
+// (or 0B) for binary. Underscores may be used to separate the digits. The same
+// can be enabled for a single field by the autobase tag option.
+func AutoIntBase() Option {
+	return func(l *Loader) {
+		for rt, f := range autoBaseParsers {
+			l.AddParser(rt, f)
+		}
+	}
+}
+
+// ExtendedBool makes the loader accept yes/no, y/n and on/off (in any case) as
+// booleans, in addition to the values accepted by strconv.ParseBool. The same
+// can be enabled for a single field by the extbool tag option.
+func ExtendedBool() Option {
+	return func(l *Loader) {
+		for rt, f := range extBoolParsers {
+			l.AddParser(rt, f)
+		}
+	}
+}
+
+// PartialFill disables transactional loads. By default, the destination is
+// modified only when the load succeeds. With PartialFill, the fields are
+// written as they are loaded, so a failed load leaves the destination
+// partially filled, including the pointers allocated on the way.
+func PartialFill() Option {
+	return func(l *Loader) {
+		l.partial = true
+	}
+}
+
+var autoBaseParsers = map[reflect.Type]ParseFunc{
+	reflect.TypeOf(int(0)): intParser(strconv.IntSize, func(v int64) interface{} {
+		return int(v)
+	}),
+	reflect.TypeOf(int8(0)): intParser(8, func(v int64) interface{} {
+		return int8(v)
+	}),
+	reflect.TypeOf(int16(0)): intParser(16, func(v int64) interface{} {
+		return int16(v)
+	}),
+	reflect.TypeOf(int32(0)): intParser(32, func(v int64) interface{} {
+		return int32(v)
+	}),
+	reflect.TypeOf(int64(0)): intParser(64, func(v int64) interface{} {
+		return v
+	}),
+	reflect.TypeOf(uint(0)): uintParser(strconv.IntSize, func(v uint64) interface{} {
+		return uint(v)
+	}),
+	reflect.TypeOf(uint8(0)): uintParser(8, func(v uint64) interface{} {
+		return uint8(v)
+	}),
+	reflect.TypeOf(uint16(0)): uintParser(16, func(v uint64) interface{} {
+		return uint16(v)
+	}),
+	reflect.TypeOf(uint32(0)): uintParser(32, func(v uint64) interface{} {
+		return uint32(v)
+	}),
+	reflect.TypeOf(uint64(0)): uintParser(64, func(v uint64) interface{} {
+		return v
+	}),
+}
+
+var extBoolParsers = map[reflect.Type]ParseFunc{
+	reflect.TypeOf(bool(false)): parseBoolExt,
+}
+
+// hasAutoBase and hasExtBool tell whether the autobase and extbool tag options
+// apply to rt.
+func hasAutoBase(rt reflect.Type) bool {
+	return autoBaseParsers[rt] != nil
+}
+
+func hasExtBool(rt reflect.Type) bool {
+	return extBoolParsers[rt] != nil
+}
+
+// intParser returns a parser of signed integers with the base given by their
+// prefix. conv converts the result to the target type.
+func intParser(bitSize int, conv func(int64) interface{}) ParseFunc {
+	return func(s string) (interface{}, error) {
+		val, err := strconv.ParseInt(s, 0, bitSize)
+		return conv(val), err
+	}
+}
+
+// uintParser is the same as intParser but for unsigned integers.
+func uintParser(bitSize int, conv func(uint64) interface{}) ParseFunc {
+	return func(s string) (interface{}, error) {
+		val, err := strconv.ParseUint(s, 0, bitSize)
+		return conv(val), err
+	}
+}
+
+func parseBoolExt(s string) (interface{}, error) {
+	switch strings.ToLower(s) {
+	case "yes", "y", "on":
+		return true, nil
+	case "no", "n", "off":
+		return false, nil
+	}
+	val, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a boolean", s)
+	}
+	return val, nil
+}
+
+// Warning is a problem found by a load which doesn't make it fail, i.e. a use
+// of a deprecated name of a variable (see the alias tag option) or, in strict
+// mode, a variable which is set but not used (see Strict).
+type Warning struct {
+	// Name is the current name of the variable, including the prefix.
+	Name string
+
+	// Alias is the deprecated name of the variable which is set, if the
+	// warning is about one.
+	Alias string
+
+	// Field is the path to the struct field, e.g. "DB.User".
+	Field string
+
+	// Origin is the origin of the value of the alias or the variable.
+	Origin Origin
+
+	// Message describes the warning.
+	Message string
+}
+
+func (w Warning) String() string {
+	if w.Alias != "" {
+		return fmt.Sprintf("%q (%s) is deprecated, use %q instead", w.Alias, w.Origin, w.Name)
+	}
+	return fmt.Sprintf("%q (%s): %s", w.Name, w.Origin, w.Message)
+}
+
+// OnWarning makes the loader call f with each warning, e.g. to log it. By
+// default, the warnings are discarded.
+func OnWarning(f func(w Warning)) Option {
+	return func(l *Loader) {
+		l.onWarning = f
+	}
+}
+
+func (s *loadState) warn(w Warning) {
+	if s.onWarning != nil {
+		s.onWarning(w)
+	}
+}
+
+// Strict makes the loader warn about the variables which are set but not used,
+// see OnWarning. These are the variables with the prefix of the load which no
+// field is loaded from, e.g. misspelled ones, and the variables of the
+// variants which are not selected, see RegisterVariants. Like the unknown
+// variable events, the unknown variables are warned about only by the loads
+// with a non-empty prefix.
+func Strict() Option {
+	return func(l *Loader) {
+		l.strict = true
+	}
+}
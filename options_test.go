@@ -0,0 +1,166 @@
+package env
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutoIntBase(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Int    int    `env:"INT"`
+		Int8   int8   `env:"INT8"`
+		Uint16 uint16 `env:"UINT16"`
+		Uint64 uint64 `env:"UINT64"`
+		Ints   []int  `env:"INTS"`
+	}
+
+	os.Clearenv()
+	os.Setenv("INT", "-0x1F")
+	os.Setenv("INT8", "0b1010")
+	os.Setenv("UINT16", "0o755")
+	os.Setenv("UINT64", "1_000")
+	os.Setenv("INTS", "010,0X10,10")
+
+	var c cfg
+	err := New(AutoIntBase()).Load(&c, "")
+	a.NoError(err)
+	a.Equal(cfg{-31, 10, 493, 1000, []int{8, 16, 10}}, c)
+
+	// Strict parsing stays the default.
+	a.Error(Load(&c, ""))
+
+	os.Setenv("INT8", "0x100")
+	a.Error(New(AutoIntBase()).Load(&c, ""), "overflow must be detected")
+}
+
+func TestAutoIntBaseTag(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Auto   uint32 `env:"AUTO,autobase"`
+		Strict uint32 `env:"STRICT"`
+	}
+
+	os.Clearenv()
+	os.Setenv("AUTO", "0xff")
+	os.Setenv("STRICT", "010")
+
+	var c cfg
+	err := Load(&c, "")
+	a.NoError(err)
+	a.Equal(cfg{255, 10}, c)
+}
+
+func TestExtendedBool(t *testing.T) {
+	a := assert.New(t)
+
+	samples := map[string]bool{
+		"yes":  true,
+		"Y":    true,
+		"ON":   true,
+		"true": true,
+		"1":    true,
+		"no":   false,
+		"n":    false,
+		"Off":  false,
+		"F":    false,
+	}
+
+	type cfg struct {
+		Bool bool `env:"BOOL"`
+	}
+	type cfgTag struct {
+		Bool bool `env:"BOOL,extbool"`
+	}
+	for s, ref := range samples {
+		os.Setenv("BOOL", s)
+
+		var c cfg
+		a.NoError(New(ExtendedBool()).Load(&c, ""))
+		a.Equal(ref, c.Bool, "parsing %q", s)
+
+		var ct cfgTag
+		a.NoError(Load(&ct, ""))
+		a.Equal(ref, ct.Bool, "parsing %q", s)
+	}
+
+	os.Setenv("BOOL", "yes")
+	var c cfg
+	a.Error(Load(&c, ""), "strict parsing must stay the default")
+
+	os.Setenv("BOOL", "maybe")
+	a.Error(New(ExtendedBool()).Load(&c, ""))
+}
+
+func TestTypeOptionsMisused(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("NAME", "x")
+	os.Setenv("LEVELS", "0x1,0x2")
+
+	var c struct {
+		Name   string        `env:"NAME,autobase"`
+		Delay  time.Duration `env:"DELAY,extbool"`
+		Levels []int         `env:"LEVELS,autobase"`
+	}
+	a.EqualError(Load(&c, ""), `env: cannot load environment config: `+
+		`"Name": autobase option used for string, which holds no integers, `+
+		`"Delay": extbool option used for time.Duration, which holds no booleans`)
+}
+
+func TestAlias(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Host string `env:"HOST,alias=HOSTNAME,alias=SERVER"`
+		Port int    `env:"PORT,alias=LISTEN_PORT"`
+	}
+
+	var warnings []string
+	l := New(OnWarning(func(w Warning) {
+		warnings = append(warnings, w.String())
+	}))
+
+	os.Clearenv()
+	os.Setenv("APP_SERVER", "server")
+	os.Setenv("APP_PORT", "80")
+
+	var c cfg
+	report, err := l.Explain(&c, "APP_")
+	a.NoError(err)
+	a.Equal(cfg{"server", 80}, c)
+	a.Equal([]string{`"APP_SERVER" (env) is deprecated, use "APP_HOST" instead`}, warnings)
+	a.Equal("APP_SERVER", report.Vars[0].Name)
+
+	// The first alias which is set wins.
+	warnings = nil
+	os.Setenv("APP_HOSTNAME", "hostname")
+	os.Setenv("APP_SERVER", "hostname")
+	a.NoError(l.Load(&c, "APP_"))
+	a.Equal(cfg{"hostname", 80}, c)
+	a.Len(warnings, 2)
+
+	// The same value under both names is fine, different values are not.
+	os.Setenv("APP_HOST", "hostname")
+	os.Setenv("APP_LISTEN_PORT", "8080")
+	err = l.Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: "APP_LISTEN_PORT" (env): conflicts with "APP_PORT", remove the deprecated name`)
+
+	// The errors name the variable which is set.
+	os.Unsetenv("APP_PORT")
+	os.Setenv("APP_LISTEN_PORT", "x")
+	err = l.Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: "APP_LISTEN_PORT" (env): cannot parse "x" as int: strconv.Atoi: parsing "x": invalid syntax`)
+
+	var bad struct {
+		Ports map[string]int `env:"PORT_,alias=OLD_PORT_"`
+		Name  string         `env:"NAME,alias="`
+	}
+	a.EqualError(Load(&bad, ""), `env: cannot load environment config: "Ports": aliases are not supported for maps and structs, "Name": empty alias`)
+}
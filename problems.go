@@ -0,0 +1,196 @@
+package env
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Problem is a single error of a failed load, see Problems.
+type Problem struct {
+	// Name is the name of the variable, including the prefix. It's empty
+	// for the errors not related to a single variable, e.g. the errors in
+	// the struct definition.
+	Name string `json:"name,omitempty"`
+
+	// Origin is the origin of the value of the variable, if it exists.
+	Origin *Origin `json:"-"`
+
+	// Missing is true if the variable is missing, see ErrMissing.
+	Missing bool `json:"missing,omitempty"`
+
+	// Message describes the error, without the name of the variable.
+	Message string `json:"message"`
+}
+
+// MarshalJSON encodes p as a JSON object with the name, origin, file and line
+// of the variable, and the message.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	type problem Problem
+	v := struct {
+		problem
+		Origin string `json:"origin,omitempty"`
+		File   string `json:"file,omitempty"`
+		Line   int    `json:"line,omitempty"`
+	}{problem: problem(p)}
+	if p.Origin != nil {
+		v.Origin, v.File, v.Line = p.Origin.String(), p.Origin.File, p.Origin.Line
+	}
+	return json.Marshal(v)
+}
+
+// Problems returns the errors of a failed load, i.e. all the errors in the tree
+// of err, flattening the errors of the load and its VarErrors.
+func Problems(err error) []Problem {
+	var ps []Problem
+	var walk func(err error)
+	walk = func(err error) {
+		var ve *VarError
+		switch e := err.(type) {
+		case *loadError:
+			for _, err := range e.errs {
+				walk(err)
+			}
+		case *VarError:
+			ve = e
+		default:
+			if !errors.As(err, &ve) {
+				ps = append(ps, Problem{Message: err.Error()})
+				return
+			}
+		}
+		if ve != nil {
+			ps = append(ps, Problem{
+				Name:    ve.Name,
+				Origin:  ve.Origin,
+				Missing: errors.Is(ve.Err, ErrMissing),
+				Message: ve.Err.Error(),
+			})
+		}
+	}
+	if err != nil {
+		walk(err)
+	}
+	return ps
+}
+
+// ReportFunc writes the report of the errors of a failed load to w, see
+// MustLoad.
+type ReportFunc func(w io.Writer, err error) error
+
+// WriteErrors writes a table of the errors of a failed load to w, one error per
+// line:
+//
+//	VARIABLE  ORIGIN  ERROR
+//	APP_HOST          variable missing
+//	APP_PORT  env     cannot parse "x" as int: ...
+func WriteErrors(w io.Writer, err error) error {
+	rows := [][]string{{"VARIABLE", "ORIGIN", "ERROR"}}
+	for _, p := range Problems(err) {
+		var origin string
+		if p.Origin != nil {
+			origin = p.Origin.String()
+		}
+		rows = append(rows, []string{p.Name, origin, p.Message})
+	}
+	return writeTable(w, rows)
+}
+
+// WriteErrorsJSON writes the errors of a failed load to w as a JSON object with
+// the list of the errors, e.g. for the deployment tools:
+//
+//	{"errors":[{"name":"APP_PORT","message":"cannot parse ...","origin":"dotenv .env:3","file":".env","line":3}]}
+func WriteErrorsJSON(w io.Writer, err error) error {
+	ps := Problems(err)
+	if ps == nil {
+		ps = []Problem{}
+	}
+	return json.NewEncoder(w).Encode(struct {
+		Errors []Problem `json:"errors"`
+	}{ps})
+}
+
+// WriteErrorsGitHub writes the errors of a failed load to w as the error
+// annotations of GitHub Actions, one per line. The errors of the values read
+// from files are annotated at their lines.
+func WriteErrorsGitHub(w io.Writer, err error) error {
+	for _, p := range Problems(err) {
+		var props []string
+		if p.Origin != nil && p.Origin.File != "" {
+			props = append(props, "file="+escapeGitHubProperty(p.Origin.File))
+			if p.Origin.Line > 0 {
+				props = append(props, "line="+strconv.Itoa(p.Origin.Line))
+			}
+		}
+		if p.Name != "" {
+			props = append(props, "title="+escapeGitHubProperty(p.Name))
+		}
+		cmd := "::error"
+		if len(props) > 0 {
+			cmd += " " + strings.Join(props, ",")
+		}
+		if _, err := fmt.Fprintf(w, "%s::%s\n", cmd, escapeGitHubData(p.Message)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var (
+	gitHubData     = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A")
+	gitHubProperty = strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C")
+)
+
+func escapeGitHubData(s string) string {
+	return gitHubData.Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return gitHubProperty.Replace(s)
+}
+
+// ReportErrors makes MustLoad write the errors by f instead of WriteErrors,
+// e.g. by WriteErrorsGitHub in CI.
+func ReportErrors(f ReportFunc) Option {
+	return func(l *Loader) {
+		l.reportErrors = f
+	}
+}
+
+// exConfig is the exit status of MustLoad, EX_CONFIG of sysexits.h.
+const exConfig = 78
+
+// stderr and exit are replaced by the tests of MustLoad.
+var (
+	stderr io.Writer = os.Stderr
+	exit             = os.Exit
+)
+
+// MustLoad is the same as Load but when the load fails, it writes the report of
+// the errors to stderr and exits with the status 78 (EX_CONFIG). It's meant to
+// be called at the start of main. By default, the report is a table written by
+// WriteErrors, see ReportErrors.
+func (l *Loader) MustLoad(dst interface{}, prefix string) {
+	err := l.Load(dst, prefix)
+	if err == nil {
+		return
+	}
+	report := l.reportErrors
+	if report == nil {
+		fmt.Fprintln(stderr, "env: cannot load environment config:")
+		report = WriteErrors
+	}
+	if err := report(stderr, err); err != nil {
+		fmt.Fprintln(stderr, err)
+	}
+	exit(exConfig)
+}
+
+// MustLoad loads dst using a Loader with no options, see Loader.MustLoad.
+func MustLoad(dst interface{}, prefix string) {
+	New().MustLoad(dst, prefix)
+}
@@ -0,0 +1,101 @@
+package env
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type problemsConfig struct {
+	Host  string `env:"HOST"`
+	Port  int    `env:"PORT"`
+	Level string `env:"LEVEL"`
+	bad   string `env:"BAD"`
+}
+
+// loadProblems loads problemsConfig from a dotenv file with an invalid port.
+func loadProblems(t *testing.T) (*Loader, error) {
+	t.Helper()
+	path := filepath.Join("testdata", "problems.env")
+	dotenv, err := Dotenv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(Sources(dotenv))
+	return l, l.Load(&problemsConfig{}, "APP_")
+}
+
+func TestProblems(t *testing.T) {
+	a := assert.New(t)
+
+	_, err := loadProblems(t)
+	o := &Origin{Source: "dotenv", File: filepath.Join("testdata", "problems.env"), Line: 2}
+	a.Equal([]Problem{
+		{Name: "APP_HOST", Missing: true, Message: "variable missing"},
+		{Name: "APP_PORT", Origin: o, Message: `cannot parse "80,443" as int: strconv.Atoi: parsing "80,443": invalid syntax`},
+		{Message: `"bad": cannot write unexported field`},
+	}, Problems(err))
+	a.Nil(Problems(nil))
+	a.Equal([]Problem{{Message: "oops"}}, Problems(errors.New("oops")))
+
+	var buf bytes.Buffer
+	a.NoError(WriteErrors(&buf, err))
+	a.Equal(`VARIABLE  ORIGIN                          ERROR
+APP_HOST                                  variable missing
+APP_PORT  dotenv testdata/problems.env:2  cannot parse "80,443" as int: strconv.Atoi: parsing "80,443": invalid syntax
+                                          "bad": cannot write unexported field
+`, buf.String())
+
+	buf.Reset()
+	a.NoError(WriteErrorsJSON(&buf, err))
+	a.Equal(`{"errors":[`+
+		`{"name":"APP_HOST","missing":true,"message":"variable missing"},`+
+		`{"name":"APP_PORT","message":"cannot parse \"80,443\" as int: strconv.Atoi: parsing \"80,443\": invalid syntax",`+
+		`"origin":"dotenv testdata/problems.env:2","file":"testdata/problems.env","line":2},`+
+		`{"message":"\"bad\": cannot write unexported field"}]}`+"\n", buf.String())
+
+	buf.Reset()
+	a.NoError(WriteErrorsGitHub(&buf, err))
+	a.Equal(`::error title=APP_HOST::variable missing
+::error file=testdata/problems.env,line=2,title=APP_PORT::cannot parse "80,443" as int: strconv.Atoi: parsing "80,443": invalid syntax
+::error::"bad": cannot write unexported field
+`, buf.String())
+
+	buf.Reset()
+	a.NoError(WriteErrorsGitHub(&buf, &VarError{Name: "A,B:C", Err: errors.New("50%\nfailed")}))
+	a.Equal("::error title=A%2CB%3AC::50%25%0Afailed\n", buf.String())
+}
+
+func TestMustLoad(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	var code int
+	stderr, exit = &buf, func(c int) { code = c }
+	defer func() { stderr, exit = os.Stderr, os.Exit }()
+
+	l, _ := loadProblems(t)
+	l.MustLoad(&problemsConfig{}, "APP_")
+	a.Equal(78, code)
+	a.Contains(buf.String(), "env: cannot load environment config:\nVARIABLE  ORIGIN")
+
+	buf.Reset()
+	ReportErrors(WriteErrorsGitHub)(l)
+	l.MustLoad(&problemsConfig{}, "APP_")
+	a.Contains(buf.String(), "::error title=APP_HOST::variable missing\n")
+	a.NotContains(buf.String(), "VARIABLE")
+
+	code = 0
+	os.Clearenv()
+	os.Setenv("APP_HOST", "localhost")
+	var c struct {
+		Host string `env:"HOST"`
+	}
+	MustLoad(&c, "APP_")
+	a.Zero(code)
+	a.Equal("localhost", c.Host)
+}
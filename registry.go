@@ -0,0 +1,80 @@
+package env
+
+import (
+	"reflect"
+	"sync"
+	"sync/atomic"
+)
+
+// ParserRegistry is implemented by the registries of parsers, i.e. Loader and
+// Registry, see RegisterParser.
+type ParserRegistry interface {
+	// AddParser registers f as the parser of all instances of rt.
+	AddParser(rt reflect.Type, f ParseFunc)
+}
+
+// Registry is a set of parsers which is safe for concurrent use.
+type Registry struct {
+	mu      sync.RWMutex
+	parsers map[reflect.Type]ParseFunc
+
+	// gen is incremented by every change of the parsers, so that the
+	// loaders know when their cached plans are stale.
+	gen uint64
+}
+
+// DefaultRegistry holds the parsers used by the loaders, e.g. those
+// registered by the init functions of the packages defining the parsed
+// types:
+//
+//	func init() {
+//		env.RegisterParser(env.DefaultRegistry, ParseLevel)
+//	}
+//
+// The parsers added to a loader itself take precedence over DefaultRegistry,
+// which takes precedence over the default parsers. A loader can use another
+// registry instead, see WithRegistry.
+var DefaultRegistry = NewRegistry()
+
+// NewRegistry returns an empty registry, see WithRegistry.
+func NewRegistry() *Registry {
+	return &Registry{parsers: make(map[reflect.Type]ParseFunc)}
+}
+
+// WithRegistry makes the loader use the parsers in r instead of those in
+// DefaultRegistry, e.g. to isolate the loaders of a library or of the tests.
+// Like with DefaultRegistry, the parsers added to r later are used as well.
+func WithRegistry(r *Registry) Option {
+	return func(l *Loader) {
+		l.registry = r
+		l.plans = new(sync.Map)
+	}
+}
+
+// reg returns the registry of the loader.
+func (l *Loader) reg() *Registry {
+	if l.registry != nil {
+		return l.registry
+	}
+	return DefaultRegistry
+}
+
+// AddParser registers f as the parser of all instances of rt.
+func (r *Registry) AddParser(rt reflect.Type, f ParseFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parsers[rt] = f
+	atomic.AddUint64(&r.gen, 1)
+}
+
+// parser returns the parser of rt, if there's one.
+func (r *Registry) parser(rt reflect.Type) (ParseFunc, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	f, ok := r.parsers[rt]
+	return f, ok
+}
+
+func (r *Registry) generation() uint64 {
+	return atomic.LoadUint64(&r.gen)
+}
@@ -0,0 +1,131 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type registryLevel int
+
+type registryPoint struct {
+	X, Y int `env:"X"`
+}
+
+func TestRegistry(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Level registryLevel `env:"LEVEL"`
+		Point registryPoint `env:"POINT_"`
+	}
+
+	os.Clearenv()
+	os.Setenv("LEVEL", "debug")
+	os.Setenv("POINT_", "1,2")
+	os.Setenv("POINT_X", "3")
+
+	r := NewRegistry()
+	l := New(WithRegistry(r))
+	var c cfg
+	err := l.Load(&c, "")
+	a.EqualError(err, `env: cannot load environment config: "LEVEL" (env): cannot parse "debug" as env.registryLevel: parsing of env.registryLevel not supported`)
+
+	RegisterParser(r, func(s string) (registryLevel, error) {
+		return registryLevel(len(s)), nil
+	})
+	c = cfg{}
+	a.NoError(l.Load(&c, ""))
+	a.Equal(cfg{Level: 5, Point: registryPoint{3, 3}}, c)
+	a.Error(Load(&c, ""), "the registry is not used by the other loaders")
+
+	// The cached plans see the parsers registered later.
+	RegisterParser(r, func(s string) (registryPoint, error) {
+		var p registryPoint
+		_, err := fmt.Sscanf(s, "%d,%d", &p.X, &p.Y)
+		return p, err
+	})
+	c = cfg{}
+	a.NoError(l.Load(&c, ""))
+	a.Equal(cfg{Level: 5, Point: registryPoint{1, 2}}, c)
+
+	// The parsers of a loader take precedence.
+	RegisterParser(l, func(s string) (registryLevel, error) {
+		return -1, nil
+	})
+	c = cfg{}
+	a.NoError(l.Load(&c, ""))
+	a.Equal(cfg{Level: -1, Point: registryPoint{1, 2}}, c)
+}
+
+type defaultRegistryLevel int
+
+func TestDefaultRegistry(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("LEVEL", "debug")
+
+	RegisterParser(DefaultRegistry, func(s string) (defaultRegistryLevel, error) {
+		return defaultRegistryLevel(len(s)), nil
+	})
+	v, err := Get[defaultRegistryLevel]("LEVEL")
+	a.NoError(err)
+	a.Equal(defaultRegistryLevel(5), v)
+
+	_, err = GetWith[defaultRegistryLevel](New(WithRegistry(NewRegistry())), "LEVEL")
+	a.Error(err, "a loader with its own registry doesn't use DefaultRegistry")
+}
+
+func TestRegistryConcurrent(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		A []string `env:"A"`
+		B int      `env:"B"`
+	}
+
+	os.Clearenv()
+	os.Setenv("A", "x,y")
+	os.Setenv("B", "1")
+
+	r := NewRegistry()
+	l := New(WithRegistry(r))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(3)
+		go func(i int) {
+			defer wg.Done()
+			// A type per goroutine, as registered by many packages.
+			rt := reflect.ArrayOf(i+1, reflect.TypeOf(""))
+			r.AddParser(rt, func(s string) (interface{}, error) {
+				return reflect.New(rt).Elem().Interface(), nil
+			})
+		}(i)
+		go func() {
+			defer wg.Done()
+			l.AddParser(reflect.TypeOf(""), func(s string) (interface{}, error) {
+				return strings.ToUpper(s), nil
+			})
+			l.RegisterEnum(map[string]registryLevel{"debug": 0})
+		}()
+		go func() {
+			defer wg.Done()
+			var c cfg
+			a.NoError(l.Load(&c, ""))
+			a.Equal(1, c.B)
+			_, err := l.Describe(&c, "")
+			a.NoError(err)
+		}()
+	}
+	wg.Wait()
+
+	var c cfg
+	a.NoError(l.Load(&c, ""))
+	a.Equal(cfg{[]string{"X", "Y"}, 1}, c)
+}
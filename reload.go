@@ -0,0 +1,212 @@
+package env
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Reloader keeps a configuration of type T, which must be a struct, up to
+// date. Each reload loads a new instance of T, so the configuration returned
+// by Current is an immutable snapshot which is safe to be shared by many
+// goroutines; it must not be modified.
+//
+// A reload is triggered explicitly by Reload, by a signal (see WatchSignals)
+// or by a change of a source (see Poll). When the reload fails, the current
+// configuration is kept and the error is passed to the error handlers, see
+// OnError.
+type Reloader[T any] struct {
+	l        *Loader
+	prefix   string
+	validate func(cfg *T) error
+
+	cur atomic.Value // *T
+
+	// mu serializes the reloads.
+	mu sync.Mutex
+
+	// hmu guards the handlers.
+	hmu        sync.Mutex
+	subs       []func(old, new *T, changes []Change)
+	errHandles []func(err error)
+}
+
+// Change is a change of a single field between two configurations.
+type Change struct {
+	// Name is the name of the variable, including the prefix.
+	Name string
+
+	// Field is the path to the struct field, e.g. "DB.User".
+	Field string
+
+	Old, New interface{}
+}
+
+// NewReloader loads the initial configuration using l. If validate is not nil,
+// it's called for each loaded configuration, the initial one included, and
+// the configuration is rejected if it returns an error. Like the subscribers,
+// validate must not reload the configuration itself.
+func NewReloader[T any](l *Loader, prefix string, validate func(cfg *T) error) (*Reloader[T], error) {
+	r := &Reloader[T]{
+		l:        l,
+		prefix:   prefix,
+		validate: validate,
+	}
+	cfg, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+	r.cur.Store(cfg)
+	return r, nil
+}
+
+// Current returns the current configuration.
+func (r *Reloader[T]) Current() *T {
+	return r.cur.Load().(*T)
+}
+
+// Subscribe registers f to be called after each successful reload which has
+// changed the configuration. The calls are serialized, so f must not reload
+// the configuration itself.
+func (r *Reloader[T]) Subscribe(f func(old, new *T, changes []Change)) {
+	r.hmu.Lock()
+	defer r.hmu.Unlock()
+	r.subs = append(r.subs, f)
+}
+
+// OnError registers f to be called with the error of each failed reload
+// triggered by a signal or a change of a source.
+func (r *Reloader[T]) OnError(f func(err error)) {
+	r.hmu.Lock()
+	defer r.hmu.Unlock()
+	r.errHandles = append(r.errHandles, f)
+}
+
+// Reload refreshes the sources of the loader (see Refresher) and loads the
+// configuration again. On error, the current configuration is kept.
+func (r *Reloader[T]) Reload() error {
+	if _, err := refresh(r.l.src); err != nil {
+		return err
+	}
+	return r.reload()
+}
+
+// WatchSignals reloads the configuration whenever the process receives one of
+// sigs, SIGHUP by default. It blocks until ctx is done.
+func (r *Reloader[T]) WatchSignals(ctx context.Context, sigs ...os.Signal) {
+	if len(sigs) == 0 {
+		sigs = []os.Signal{syscall.SIGHUP}
+	}
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sigs...)
+	defer signal.Stop(ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ch:
+			r.handle(r.Reload())
+		}
+	}
+}
+
+// Poll refreshes the sources of the loader every interval (see Refresher) and
+// reloads the configuration when they have changed. It blocks until ctx is
+// done.
+func (r *Reloader[T]) Poll(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			changed, err := refresh(r.l.src)
+			if err == nil && changed {
+				err = r.reload()
+			}
+			r.handle(err)
+		}
+	}
+}
+
+func (r *Reloader[T]) handle(err error) {
+	if err == nil {
+		return
+	}
+	r.hmu.Lock()
+	handles := r.errHandles
+	r.hmu.Unlock()
+	for _, f := range handles {
+		f(err)
+	}
+}
+
+// reload loads and stores the configuration. The reloads are serialized, so
+// that a slow reload doesn't overwrite the configuration stored by a later one.
+func (r *Reloader[T]) reload() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cfg, err := r.load()
+	if err != nil {
+		return err
+	}
+	old := r.Current()
+	changes := r.diff(old, cfg)
+	if len(changes) == 0 {
+		return nil
+	}
+	r.cur.Store(cfg)
+	r.hmu.Lock()
+	subs := r.subs
+	r.hmu.Unlock()
+	for _, f := range subs {
+		f(old, cfg, changes)
+	}
+	return nil
+}
+
+func (r *Reloader[T]) load() (*T, error) {
+	cfg := new(T)
+	if err := r.l.Load(cfg, r.prefix); err != nil {
+		return nil, err
+	}
+	if r.validate != nil {
+		if err := r.validate(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
+
+// diff returns the changes of the fields loaded from variables between old and
+// new. The fields of the optional sections which are missing in one of them
+// change from or to nil.
+func (r *Reloader[T]) diff(old, new *T) []Change {
+	oldVals := make(map[string]interface{})
+	r.l.walk(reflect.ValueOf(old), r.prefix, existing, func(f field) error {
+		oldVals[f.path] = f.value.Interface()
+		return nil
+	})
+	var changes []Change
+	r.l.walk(reflect.ValueOf(new), r.prefix, existing, func(f field) error {
+		v := f.value.Interface()
+		if ov := oldVals[f.path]; !reflect.DeepEqual(ov, v) {
+			changes = append(changes, Change{f.name, f.path, ov, v})
+		}
+		delete(oldVals, f.path)
+		return nil
+	})
+	r.l.walk(reflect.ValueOf(old), r.prefix, existing, func(f field) error {
+		if ov, ok := oldVals[f.path]; ok {
+			changes = append(changes, Change{f.name, f.path, ov, nil})
+		}
+		return nil
+	})
+	return changes
+}
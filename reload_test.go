@@ -0,0 +1,206 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type reloadConfig struct {
+	LogLevel  string `env:"LOG_LEVEL"`
+	RateLimit int    `env:"RATE_LIMIT"`
+}
+
+func writeDotenv(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestReloader(t *testing.T) (*Reloader[reloadConfig], string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), ".env")
+	writeDotenv(t, path, "APP_LOG_LEVEL=info\nAPP_RATE_LIMIT=10\n")
+	dotenv, err := Dotenv(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	validate := func(cfg *reloadConfig) error {
+		if cfg.RateLimit <= 0 {
+			return errors.New("rate limit must be positive")
+		}
+		return nil
+	}
+	r, err := NewReloader(New(Sources(dotenv)), "APP_", validate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, path
+}
+
+func TestReloader(t *testing.T) {
+	a := assert.New(t)
+
+	r, path := newTestReloader(t)
+	first := r.Current()
+	a.Equal(&reloadConfig{"info", 10}, first)
+
+	var got []Change
+	r.Subscribe(func(old, new *reloadConfig, changes []Change) {
+		a.Same(first, old)
+		got = changes
+	})
+
+	// Nothing has changed.
+	a.NoError(r.Reload())
+	a.Same(first, r.Current())
+	a.Nil(got)
+
+	writeDotenv(t, path, "APP_LOG_LEVEL=debug\nAPP_RATE_LIMIT=10\n")
+	a.NoError(r.Reload())
+	a.Equal(&reloadConfig{"debug", 10}, r.Current())
+	a.Equal(&reloadConfig{"info", 10}, first, "snapshot must not change")
+	a.Equal([]Change{{"APP_LOG_LEVEL", "LogLevel", "info", "debug"}}, got)
+
+	// Failed reloads keep the previous configuration.
+	second := r.Current()
+	writeDotenv(t, path, "APP_LOG_LEVEL=warn\nAPP_RATE_LIMIT=0\n")
+	a.EqualError(r.Reload(), "rate limit must be positive")
+	a.Same(second, r.Current())
+
+	writeDotenv(t, path, "APP_LOG_LEVEL=warn\nAPP_RATE_LIMIT=x\n")
+	a.Error(r.Reload())
+	a.Same(second, r.Current())
+
+	writeDotenv(t, path, "APP_LOG_LEVEL=warn\n")
+	a.Error(r.Reload())
+	a.Same(second, r.Current())
+}
+
+func TestReloaderConcurrent(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_LOG_LEVEL", "v1")
+	os.Setenv("APP_RATE_LIMIT", "1")
+	started, release := make(chan struct{}), make(chan struct{})
+	var calls int32
+	validate := func(cfg *reloadConfig) error {
+		// The first reload is slow.
+		if atomic.AddInt32(&calls, 1) == 2 {
+			close(started)
+			<-release
+		}
+		return nil
+	}
+	r, err := NewReloader(New(), "APP_", validate)
+	a.NoError(err)
+
+	done := make(chan error)
+	os.Setenv("APP_LOG_LEVEL", "v2")
+	go func() { done <- r.Reload() }()
+	<-started
+	os.Setenv("APP_LOG_LEVEL", "v3")
+	go func() { done <- r.Reload() }()
+	// Let the second reload overtake the first one, if it can.
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	a.NoError(<-done)
+	a.NoError(<-done)
+	a.Equal("v3", r.Current().LogLevel)
+}
+
+func TestReloaderInitialError(t *testing.T) {
+	a := assert.New(t)
+
+	src := Map("test", map[string]string{"APP_LOG_LEVEL": "info"})
+	_, err := NewReloader[reloadConfig](New(Sources(src)), "APP_", nil)
+	a.Error(err)
+}
+
+func TestReloaderPoll(t *testing.T) {
+	a := assert.New(t)
+
+	r, path := newTestReloader(t)
+	updated := make(chan *reloadConfig, 1)
+	r.Subscribe(func(_, new *reloadConfig, _ []Change) {
+		updated <- new
+	})
+	errs := make(chan error, 1)
+	r.OnError(func(err error) {
+		errs <- err
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.Poll(ctx, time.Millisecond)
+
+	writeDotenv(t, path, "APP_LOG_LEVEL=info\nAPP_RATE_LIMIT=20\n")
+	select {
+	case cfg := <-updated:
+		a.Equal(&reloadConfig{"info", 20}, cfg)
+	case <-time.After(5 * time.Second):
+		t.Fatal("configuration not reloaded")
+	}
+
+	writeDotenv(t, path, "APP_LOG_LEVEL=info\nAPP_RATE_LIMIT=-1\n")
+	select {
+	case err := <-errs:
+		a.EqualError(err, "rate limit must be positive")
+	case <-time.After(5 * time.Second):
+		t.Fatal("error not reported")
+	}
+	a.Equal(&reloadConfig{"info", 20}, r.Current())
+}
+
+func TestReloaderSignal(t *testing.T) {
+	a := assert.New(t)
+
+	r, path := newTestReloader(t)
+	updated := make(chan *reloadConfig, 1)
+	r.Subscribe(func(_, new *reloadConfig, _ []Change) {
+		updated <- new
+	})
+
+	// Don't let the signal terminate the test before WatchSignals is
+	// ready.
+	guard := make(chan os.Signal, 1)
+	signal.Notify(guard, syscall.SIGHUP)
+	defer signal.Stop(guard)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		r.WatchSignals(ctx)
+		close(done)
+	}()
+
+	writeDotenv(t, path, "APP_LOG_LEVEL=error\nAPP_RATE_LIMIT=10\n")
+	p, err := os.FindProcess(os.Getpid())
+	a.NoError(err)
+	deadline := time.After(5 * time.Second)
+	for {
+		// The signal may arrive before WatchSignals is ready.
+		a.NoError(p.Signal(syscall.SIGHUP))
+		select {
+		case cfg := <-updated:
+			a.Equal(&reloadConfig{"error", 10}, cfg)
+			cancel()
+			<-done
+			return
+		case <-time.After(10 * time.Millisecond):
+		case <-deadline:
+			t.Fatal("configuration not reloaded")
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package env
+
+import (
+	"context"
+	"io"
+)
+
+// Provenance tells where the value of a loaded variable came from.
+type Provenance struct {
+	// Name is the name of the variable, including the prefix.
+	Name string
+
+	// Field is the path to the struct field, e.g. "DB.User".
+	Field string
+
+	Origin Origin
+
+	// Secret is true if the value is secret, i.e. the field has the secret
+	// tag option or the value was resolved from a reference.
+	Secret bool
+}
+
+// Report lists the provenance of all the variables used by a load, in the
+// order in which they were loaded.
+type Report struct {
+	Vars []Provenance
+
+	// aliases are the other names of the loaded variables which have
+	// deprecated names, by the names they were loaded by, see the alias tag
+	// option.
+	aliases map[string][]string
+
+	// refVars are the variables read by the env:// references the loaded
+	// variables were resolved from, by the names of the loaded variables,
+	// see EnvResolver.
+	refVars map[string]string
+}
+
+// names returns the name of the loaded variable called name, its other names,
+// see aliases, and the variable its value was read from, see refVars.
+func (r *Report) names(name string) []string {
+	names := append([]string{name}, r.aliases[name]...)
+	if ref, ok := r.refVars[name]; ok {
+		names = append(names, ref)
+	}
+	return names
+}
+
+// Origin returns the origin of the variable called name.
+func (r *Report) Origin(name string) (Origin, bool) {
+	for _, p := range r.Vars {
+		if p.Name == name {
+			return p.Origin, true
+		}
+	}
+	return Origin{}, false
+}
+
+// Write writes a table of the variables and their origins to w.
+func (r *Report) Write(w io.Writer) error {
+	rows := [][]string{{"VARIABLE", "FIELD", "ORIGIN"}}
+	for _, p := range r.Vars {
+		rows = append(rows, []string{p.Name, p.Field, p.Origin.String()})
+	}
+	return writeTable(w, rows)
+}
+
+// Explain is the same as Load but it also reports where the values of all the
+// loaded variables came from. The report is returned even if the load fails,
+// in which case it covers only the variables loaded successfully.
+func (l *Loader) Explain(dst interface{}, prefix string) (*Report, error) {
+	s := l.newLoadState(context.Background())
+	errs := s.load(dst, prefix)
+	if len(errs) > 0 {
+		return &s.report, &loadError{errs}
+	}
+	return &s.report, nil
+}
+
+// Explain explains dst using a Loader with no options, see Loader.Explain.
+func Explain(dst interface{}, prefix string) (*Report, error) {
+	return New().Explain(dst, prefix)
+}
@@ -0,0 +1,282 @@
+package env
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultResolveTimeout limits the time spent by resolving references, see
+// ResolveTimeout.
+const defaultResolveTimeout = 30 * time.Second
+
+// Resolver resolves references to values stored elsewhere, e.g. in files or
+// secret stores. The references are URIs like file:///run/secrets/db or
+// vault://kv/db#password; see ResolveScheme.
+type Resolver interface {
+	// Resolve returns the value ref refers to. It should give up when ctx
+	// is done.
+	Resolve(ctx context.Context, ref *url.URL) (string, error)
+}
+
+// ResolverFunc is an adapter to use an ordinary function as a Resolver.
+type ResolverFunc func(ctx context.Context, ref *url.URL) (string, error)
+
+// Resolve calls f(ctx, ref).
+func (f ResolverFunc) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	return f(ctx, ref)
+}
+
+// ResolveScheme makes the loader resolve the values which are URIs with the
+// given scheme by r before they are parsed. Only the references in the
+// variables used by the load are resolved, concurrently for the variables of
+// the fields, each of them at most once per load, and the resolved values are
+// never included in the errors.
+func ResolveScheme(scheme string, r Resolver) Option {
+	return func(l *Loader) {
+		if l.resolvers == nil {
+			l.resolvers = make(map[string]Resolver)
+		}
+		l.resolvers[strings.ToLower(scheme)] = r
+	}
+}
+
+// DefaultResolvers makes the loader resolve the file:// and env:// references,
+// see FileResolver and EnvResolver. Only the URIs with // are references, the
+// other values with these schemes, e.g. env:production, are kept as they are.
+func DefaultResolvers() Option {
+	return func(l *Loader) {
+		ResolveScheme("file", FileResolver())(l)
+		ResolveScheme("env", EnvResolver())(l)
+	}
+}
+
+// ResolveTimeout limits the time spent by resolving all the references of
+// a single load. The default is 30 seconds.
+func ResolveTimeout(d time.Duration) Option {
+	return func(l *Loader) {
+		l.resolveTimeout = d
+	}
+}
+
+// FileResolver returns a resolver of file:///path references. The value is the
+// content of the file without the trailing newlines.
+func FileResolver() Resolver {
+	return ResolverFunc(func(ctx context.Context, ref *url.URL) (string, error) {
+		if ref.Host != "" && ref.Host != "localhost" {
+			return "", fmt.Errorf("remote files are not supported")
+		}
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		b, err := os.ReadFile(ref.Path)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(b), "\r\n"), nil
+	})
+}
+
+// EnvResolver returns a resolver of env://NAME references. The value is the
+// value of the NAME variable in the environment of the process, which must
+// exist.
+func EnvResolver() Resolver {
+	return ResolverFunc(func(_ context.Context, ref *url.URL) (string, error) {
+		s, ok := os.LookupEnv(ref.Host)
+		if !ok {
+			return "", fmt.Errorf("variable %q missing", ref.Host)
+		}
+		return s, nil
+	})
+}
+
+// hierarchicalSchemes are the schemes of the references which must be
+// scheme://... URIs, so that the values like env:production are not mistaken
+// for references, see DefaultResolvers.
+var hierarchicalSchemes = map[string]bool{"file": true, "env": true}
+
+// isReference tells whether value, parsed as ref, is a reference with respect
+// to hierarchicalSchemes.
+func isReference(value string, ref *url.URL) bool {
+	return !hierarchicalSchemes[ref.Scheme] || strings.HasPrefix(value[len(ref.Scheme):], "://")
+}
+
+// resolution is a reference resolved by a load, see lookupVar.
+type resolution struct {
+	v   Var
+	err error
+}
+
+// resolving starts resolving the references of a load, which is limited by
+// the resolve timeout, and returns the function to be called when the load
+// ends.
+func (s *loadState) resolving() context.CancelFunc {
+	timeout := s.resolveTimeout
+	if timeout == 0 {
+		timeout = defaultResolveTimeout
+	}
+	var cancel context.CancelFunc
+	s.refCtx, cancel = context.WithTimeout(s.ctx, timeout)
+	return cancel
+}
+
+// reference returns the reference in v and its resolver, if v is a reference.
+func (s *loadState) reference(v Var) (*url.URL, Resolver) {
+	if len(s.resolvers) == 0 {
+		return nil, nil
+	}
+	ref, err := url.Parse(v.Value)
+	if err != nil || !isReference(v.Value, ref) {
+		return nil, nil
+	}
+	r := s.resolvers[ref.Scheme]
+	if r == nil {
+		return nil, nil
+	}
+	return ref, r
+}
+
+// lookupVar returns the variable called name. If it's a reference, it's
+// resolved, at most once per load, and the resolved value is returned with
+// the reference in its origin. The references are resolved only when the
+// variables are used, so the unused ones can't fail the load.
+func (s *loadState) lookupVar(name string) (Var, bool, error) {
+	v, ok := s.src.Lookup(name)
+	if !ok {
+		return v, false, nil
+	}
+	ref, r := s.reference(v)
+	if r == nil {
+		return v, true, nil
+	}
+	s.refMu.Lock()
+	res, done := s.refs[name]
+	s.refMu.Unlock()
+	if !done {
+		res = s.resolveVar(name, v, ref, r)
+	}
+	return res.v, true, res.err
+}
+
+// prefetch resolves the references in the variables called names concurrently,
+// so that lookupVar finds them resolved.
+func (s *loadState) prefetch(names []string) {
+	var wg sync.WaitGroup
+	seen := make(map[string]bool)
+	for _, name := range names {
+		v, ok := s.src.Lookup(name)
+		if !ok || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if ref, r := s.reference(v); r != nil {
+			wg.Add(1)
+			go func(name string) {
+				defer wg.Done()
+				s.resolveVar(name, v, ref, r)
+			}(name)
+		}
+	}
+	wg.Wait()
+}
+
+// resolveVar resolves the reference ref in v, the variable called name, by r
+// and caches the result.
+func (s *loadState) resolveVar(name string, v Var, ref *url.URL, r Resolver) resolution {
+	ctx := s.refCtx
+	if ctx == nil {
+		ctx = s.ctx
+	}
+	value, err := r.Resolve(ctx, ref)
+	redactedRef := ref.Redacted()
+	var res resolution
+	if err != nil {
+		err = fmt.Errorf("cannot resolve %q: %w", redactedRef, err)
+		res = resolution{v, &VarError{Name: name, Origin: &v.Origin, Err: err}}
+	} else {
+		o := v.Origin
+		o.Ref = redactedRef
+		res = resolution{v: Var{value, o}}
+	}
+	s.refMu.Lock()
+	defer s.refMu.Unlock()
+	if s.refs == nil {
+		s.refs = make(map[string]resolution)
+	}
+	s.refs[name] = res
+	return res
+}
+
+// readNames returns the names of the variables read by the fields of the
+// struct type rt, to be prefetched. The variables read by the variants and
+// the EnvUnmarshalers are resolved when they're looked up.
+func (s *loadState) readNames(rt reflect.Type, prefix string) []string {
+	for rt != nil && rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt == nil || rt.Kind() != reflect.Struct {
+		return nil
+	}
+	var names []string
+	for _, pf := range s.plan(rt, prefix) {
+		if pf.err != nil {
+			continue
+		}
+		if isMap(pf.typeIn(rt)) {
+			names = append(names, s.namesPrefixed(pf.name)...)
+			continue
+		}
+		names = append(names, pf.name)
+		names = append(names, pf.aliases...)
+	}
+	return names
+}
+
+// redacted is shown in place of the secret values.
+const redacted = "<redacted>"
+
+// isSecret tells whether the value of v is secret, i.e. it was resolved from
+// a reference or secret is set by the secret tag option.
+func isSecret(v Var, secret bool) bool {
+	return secret || v.Origin.Ref != ""
+}
+
+// quoteValue returns the value of v as it's shown in the errors, i.e. quoted,
+// or <redacted> if it's secret.
+func quoteValue(v Var, secret bool) string {
+	if isSecret(v, secret) {
+		return redacted
+	}
+	return strconv.Quote(v.Value)
+}
+
+// valueError returns an error described by msg wrapping err, the error of the
+// value of v. The msg must show the value by quoteValue. The message of err is
+// left out when the value is secret, since it may repeat the value.
+func valueError(msg string, v Var, secret bool, err error) error {
+	if isSecret(v, secret) {
+		return &secretError{msg, err}
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// secretError is an error of a secret value. Its message doesn't include the
+// message of the wrapped error, see valueError.
+type secretError struct {
+	msg string
+	err error
+}
+
+func (e *secretError) Error() string {
+	return e.msg
+}
+
+func (e *secretError) Unwrap() error {
+	return e.err
+}
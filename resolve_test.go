@@ -0,0 +1,184 @@
+package env
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeVault is an in-memory secret store resolving vault://path#key.
+type fakeVault map[string]map[string]string
+
+func (v fakeVault) Resolve(ctx context.Context, ref *url.URL) (string, error) {
+	secret, ok := v[ref.Host+ref.Path][ref.Fragment]
+	if !ok {
+		return "", errors.New("secret not found")
+	}
+	return secret, nil
+}
+
+func TestResolve(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Password string `env:"DB_PASSWORD"`
+		User     string `env:"DB_USER"`
+		Key      string `env:"KEY"`
+		Token    string `env:"TOKEN"`
+		Plain    string `env:"PLAIN"`
+	}
+
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key")
+	a.NoError(os.WriteFile(keyFile, []byte("s3cr3t\n"), 0600))
+
+	os.Clearenv()
+	os.Setenv("APP_DB_PASSWORD", "vault://kv/db#password")
+	os.Setenv("APP_DB_USER", "vault://kv/db#user")
+	os.Setenv("APP_KEY", "file://"+keyFile)
+	os.Setenv("APP_TOKEN", "env://CI_TOKEN")
+	os.Setenv("APP_PLAIN", "https://example.org")
+	os.Setenv("CI_TOKEN", "t0k3n")
+
+	vault := fakeVault{"kv/db": {"user": "joe", "password": "hunter2"}}
+	l := New(DefaultResolvers(), ResolveScheme("vault", vault))
+
+	var c cfg
+	report, err := l.Explain(&c, "APP_")
+	a.NoError(err)
+	a.Equal(cfg{"hunter2", "joe", "s3cr3t", "t0k3n", "https://example.org"}, c)
+
+	o, _ := report.Origin("APP_DB_PASSWORD")
+	a.Equal(Origin{Source: "env", Ref: "vault://kv/db#password"}, o)
+	a.Equal("env -> vault://kv/db#password", o.String())
+
+	os.Setenv("APP_DB_USER", "vault://kv/db#login")
+	os.Setenv("APP_KEY", "file://"+filepath.Join(dir, "missing"))
+	err = l.Load(&c, "APP_")
+	a.Error(err)
+	a.Contains(err.Error(), `"APP_DB_USER" (env): cannot resolve "vault://kv/db#login": secret not found`)
+	a.Contains(err.Error(), `"APP_KEY" (env): cannot resolve`)
+}
+
+func TestResolveOpaque(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Mode  string `env:"MODE"`
+		Notes string `env:"NOTES"`
+	}
+
+	os.Clearenv()
+	os.Setenv("APP_MODE", "env:production")
+	os.Setenv("APP_NOTES", "file:notes.txt")
+
+	var c cfg
+	a.NoError(New(DefaultResolvers()).Load(&c, "APP_"))
+	a.Equal(cfg{"env:production", "file:notes.txt"}, c)
+}
+
+func TestResolveUnused(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_HOST", "env://HOST")
+	os.Setenv("APP_UNUSED", "env://NOPE")
+	os.Setenv("UNRELATED_REPO", "file:///nonexistent/repo")
+	os.Setenv("HOST", "localhost")
+
+	l := New(DefaultResolvers())
+	var c struct {
+		Host string `env:"HOST"`
+	}
+	a.NoError(l.Load(&c, "APP_"))
+	a.Equal("localhost", c.Host)
+
+	var d struct {
+		Host string `env:"APP_HOST"`
+	}
+	a.NoError(l.Load(&d, ""))
+	a.Equal("localhost", d.Host)
+
+	os.Setenv("APP_HOST", "env://NOPE")
+	a.EqualError(l.Load(&c, "APP_"), `env: cannot load environment config: "APP_HOST" (env): cannot resolve "env://NOPE": variable "NOPE" missing`)
+}
+
+func TestResolveRedacted(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Port  int            `env:"PORT"`
+		Ports map[string]int `env:"PORT_"`
+	}
+
+	os.Clearenv()
+	os.Setenv("APP_PORT", "env://SECRET_PORT")
+	os.Setenv("APP_PORT_http", "env://SECRET_PORT")
+	os.Setenv("SECRET_PORT", "hunter2")
+
+	var c cfg
+	err := New(DefaultResolvers()).Load(&c, "APP_")
+	a.Error(err)
+	a.NotContains(err.Error(), "hunter2")
+	a.Contains(err.Error(), `cannot parse <redacted> as int`)
+}
+
+func TestResolveConcurrently(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		A string `env:"A"`
+		B string `env:"B"`
+		C string `env:"C"`
+	}
+
+	// Each resolution waits for all of them to start, so they must run
+	// concurrently.
+	var wg sync.WaitGroup
+	wg.Add(3)
+	r := ResolverFunc(func(ctx context.Context, ref *url.URL) (string, error) {
+		wg.Done()
+		wg.Wait()
+		return ref.Opaque, nil
+	})
+
+	src := Map("test", map[string]string{
+		"A": "slow:a",
+		"B": "slow:b",
+		"C": "slow:c",
+	})
+	var c cfg
+	a.NoError(New(Sources(src), ResolveScheme("slow", r)).Load(&c, ""))
+	a.Equal(cfg{"a", "b", "c"}, c)
+}
+
+func TestResolveTimeout(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		A string `env:"A"`
+	}
+
+	hang := ResolverFunc(func(ctx context.Context, ref *url.URL) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	})
+	src := Map("test", map[string]string{"A": "hang:a"})
+
+	var c cfg
+	l := New(Sources(src), ResolveScheme("hang", hang), ResolveTimeout(time.Millisecond))
+	err := l.Load(&c, "")
+	a.True(errors.Is(err.(*loadError).errs[0], context.DeadlineExceeded))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = New(Sources(src), ResolveScheme("hang", hang)).LoadContext(ctx, &c, "")
+	a.True(errors.Is(err.(*loadError).errs[0], context.Canceled))
+}
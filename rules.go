@@ -0,0 +1,242 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// rules are the conditional requirements of a field, see the requiredIf,
+// excludes and oneOf tag options.
+type rules struct {
+	// ifName is the name of the variable, including the prefix, which makes
+	// the field required when it's true or, if hasValue is set, equal to
+	// ifValue.
+	ifName, ifValue string
+	hasValue        bool
+
+	// excludes are the names of the variables, including the prefix, which
+	// cannot be set together with the field.
+	excludes []string
+
+	// group is the oneOf group of the field and member is the path to the
+	// field, or to the struct, which is the member of the group.
+	group, member string
+}
+
+// conditional tells whether the field may be missing, depending on the other
+// variables.
+func (r rules) conditional() bool {
+	return r.ifName != "" || r.group != ""
+}
+
+// requiredIf returns the condition of the requiredIf tag option, e.g.
+// "PREFIX_TLS_ENABLED" or "PREFIX_MODE=tcp".
+func (r rules) requiredIf() string {
+	if r.hasValue {
+		return r.ifName + "=" + r.ifValue
+	}
+	return r.ifName
+}
+
+// compileRules returns the rules of a field given by opts, which are added to
+// the rules inherited from the struct the field is in. The names in opts are
+// prefixed by prefix, like the name of the field.
+func compileRules(inherited rules, opts tagOptions, prefix, path string, isPrefix, isStruct bool) (rules, error) {
+	r := inherited
+	r.excludes = nil
+	if opts.requiredIf != "" {
+		if isPrefix && !isStruct {
+			return r, errors.New("requiredIf option is supported only for variables and structs")
+		}
+		if r.ifName != "" {
+			return r, errors.New("requiredIf option inside a struct which has one")
+		}
+		name, value, ok := strings.Cut(opts.requiredIf, "=")
+		r.ifName, r.ifValue, r.hasValue = prefix+name, value, ok
+	}
+	if len(opts.excludes) > 0 {
+		if isPrefix {
+			return r, errors.New("excludes option is supported only for variables")
+		}
+		for _, name := range opts.excludes {
+			r.excludes = append(r.excludes, prefix+name)
+		}
+	}
+	if opts.oneOf != "" {
+		if isPrefix && !isStruct {
+			return r, errors.New("oneOf option is supported only for variables and structs")
+		}
+		if r.group != "" {
+			return r, fmt.Errorf("oneOf group %q inside group %q", opts.oneOf, r.group)
+		}
+		r.group, r.member = opts.oneOf, path
+	}
+	return r, nil
+}
+
+// groupMember is a member of a oneOf group, i.e. a single variable or all the
+// variables of a struct.
+type groupMember struct {
+	names []string
+	set   string
+}
+
+func (m groupMember) String() string {
+	return strings.Join(m.names, "+")
+}
+
+// checkRules checks the conditional requirements of the fields of the struct
+// rt, see the requiredIf, excludes and oneOf tag options. The variables which
+// may be missing are skipped by loadVar, so the missing ones are reported
+// here.
+func (s *loadState) checkRules(rt reflect.Type, prefix string) []error {
+	for rt.Kind() == reflect.Ptr {
+		rt = rt.Elem()
+	}
+	if rt.Kind() != reflect.Struct {
+		return nil
+	}
+	type memberKey struct{ group, member string }
+	var errs []error
+	var groups []string
+	members := make(map[string][]*groupMember)
+	byKey := make(map[memberKey]*groupMember)
+	parsers := make(map[string]ParseFunc)
+	for _, pf := range s.plan(rt, prefix) {
+		if pf.err != nil {
+			continue
+		}
+		parsers[pf.name] = pf.parse
+		if !s.active(pf) {
+			continue
+		}
+		r := pf.rules
+		name, v, ok := s.lookupAny(pf.field)
+		for _, ex := range r.excludes {
+			if _, exSet := s.src.Lookup(ex); ok && exSet {
+				err := fmt.Errorf("cannot be set together with %q", ex)
+				errs = append(errs, &VarError{Name: name, Origin: &v.Origin, Err: err})
+			}
+		}
+		if r.group == "" {
+			continue
+		}
+		k := memberKey{r.group, r.member}
+		m := byKey[k]
+		if m == nil {
+			if len(members[r.group]) == 0 {
+				groups = append(groups, r.group)
+			}
+			m = &groupMember{}
+			byKey[k] = m
+			members[r.group] = append(members[r.group], m)
+		}
+		m.names = append(m.names, pf.name)
+		if ok && m.set == "" {
+			m.set = name
+		}
+	}
+
+	for _, pf := range s.plan(rt, prefix) {
+		r := pf.rules
+		if pf.err != nil || !r.conditional() || !s.active(pf) {
+			continue
+		}
+		if _, _, ok := s.lookupAny(pf.field); ok {
+			continue
+		}
+		var why string
+		if r.ifName != "" {
+			// The error of the reference is reported by the field
+			// of the variable, if there's one.
+			v, ok, err := s.lookupVar(r.ifName)
+			if !ok || err != nil || !s.holds(r, v.Value, parsers[r.ifName]) {
+				continue
+			}
+			why = "required if " + r.requiredIf()
+		}
+		if r.group != "" {
+			m := byKey[memberKey{r.group, r.member}]
+			if m.set == "" {
+				continue
+			}
+			if why == "" {
+				why = fmt.Sprintf("required since %s is set", m.set)
+			}
+		}
+		err := fmt.Errorf("%w, %s", ErrMissing, why)
+		errs = append(errs, &VarError{Name: pf.name, Err: err})
+	}
+
+	for _, group := range groups {
+		var set []string
+		for _, m := range members[group] {
+			if m.set != "" {
+				set = append(set, m.String())
+			}
+		}
+		switch {
+		case len(set) == 0:
+			list := make([]string, len(members[group]))
+			for i, m := range members[group] {
+				list[i] = m.String()
+			}
+			msg := "oneOf group %q: one of %s must be set"
+			errs = append(errs, fmt.Errorf(msg, group, strings.Join(list, ", ")))
+		case len(set) > 1:
+			msg := "oneOf group %q: %s cannot be set together"
+			errs = append(errs, fmt.Errorf(msg, group, strings.Join(set, " and ")))
+		}
+	}
+	return errs
+}
+
+// active tells whether pf is loaded, i.e. all the sections it's in are
+// present.
+func (s *loadState) active(pf planField) bool {
+	for _, sec := range pf.sections {
+		if !s.present(sec) {
+			return false
+		}
+	}
+	return true
+}
+
+// holds tells whether the condition of r, the requiredIf tag option, holds for
+// value, which is the value of the variable the condition is about. Unless the
+// condition has a value, the value is parsed as a bool by parse, the parser of
+// the field of the variable, if it parses bools, or by the bool parser of the
+// loader, so that the extbool option and ExtendedBool apply.
+func (s *loadState) holds(r rules, value string, parse ParseFunc) bool {
+	if r.hasValue {
+		return value == r.ifValue
+	}
+	if parse != nil {
+		if v, err := parse(value); err == nil {
+			if rv := reflect.ValueOf(v); rv.Kind() == reflect.Bool {
+				return rv.Bool()
+			}
+		}
+	}
+	v, err := s.parser(boolType, tagOptions{})(value)
+	b, _ := v.(bool)
+	return err == nil && b
+}
+
+var boolType = reflect.TypeOf(false)
+
+// lookupAny returns the variable of f by its name or any of its aliases,
+// without reporting the aliases.
+func (s *loadState) lookupAny(f field) (string, Var, bool) {
+	if v, ok := s.src.Lookup(f.name); ok {
+		return f.name, v, true
+	}
+	for _, alias := range f.aliases {
+		if v, ok := s.src.Lookup(alias); ok {
+			return alias, v, true
+		}
+	}
+	return f.name, Var{}, false
+}
@@ -0,0 +1,126 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type rulesConfig struct {
+	TLSEnabled bool   `env:"TLS_ENABLED"`
+	TLSCert    string `env:"TLS_CERT,requiredIf=TLS_ENABLED"`
+	Mode       string `env:"MODE"`
+	Socket     string `env:"SOCKET,requiredIf=MODE=unix"`
+	Token      string `env:"TOKEN,excludes=PASSWORD,excludes=CERT"`
+
+	DSN  string `env:"DSN,oneOf=db"`
+	Addr struct {
+		Host string `env:"HOST"`
+		Port int    `env:"PORT"`
+	} `env:"DB_,oneOf=db"`
+}
+
+func TestRules(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_TLS_ENABLED", "false")
+	os.Setenv("APP_MODE", "tcp")
+	os.Setenv("APP_TOKEN", "t0k3n")
+	os.Setenv("APP_DSN", "postgres://db")
+
+	var c rulesConfig
+	a.NoError(Load(&c, "APP_"))
+	a.Equal("postgres://db", c.DSN)
+
+	os.Setenv("APP_TLS_ENABLED", "true")
+	os.Setenv("APP_MODE", "unix")
+	os.Setenv("APP_PASSWORD", "hunter2")
+	os.Setenv("APP_DB_HOST", "localhost")
+	err := Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: `+
+		`"APP_TOKEN" (env): cannot be set together with "APP_PASSWORD", `+
+		`"APP_TLS_CERT": variable missing, required if APP_TLS_ENABLED, `+
+		`"APP_SOCKET": variable missing, required if APP_MODE=unix, `+
+		`"APP_DB_PORT": variable missing, required since APP_DB_HOST is set, `+
+		`oneOf group "db": APP_DSN and APP_DB_HOST+APP_DB_PORT cannot be set together`)
+
+	os.Clearenv()
+	os.Setenv("APP_TLS_ENABLED", "1")
+	os.Setenv("APP_TLS_CERT", "cert")
+	os.Setenv("APP_MODE", "unix")
+	os.Setenv("APP_SOCKET", "/run/app.sock")
+	os.Setenv("APP_TOKEN", "t0k3n")
+	err = Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: oneOf group "db": one of APP_DSN, APP_DB_HOST+APP_DB_PORT must be set`)
+
+	os.Setenv("APP_DB_HOST", "localhost")
+	os.Setenv("APP_DB_PORT", "5432")
+	a.NoError(Load(&c, "APP_"))
+	a.Equal("cert", c.TLSCert)
+	a.Equal("/run/app.sock", c.Socket)
+	a.Equal(5432, c.Addr.Port)
+}
+
+func TestRulesExtendedBool(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		TLSEnabled bool   `env:"TLS_ENABLED,extbool"`
+		TLSCert    string `env:"TLS_CERT,requiredIf=TLS_ENABLED"`
+		Debug      string `env:"DEBUG"`
+		Trace      string `env:"TRACE,requiredIf=DEBUG"`
+	}
+
+	os.Clearenv()
+	os.Setenv("TLS_ENABLED", "yes")
+	os.Setenv("DEBUG", "on")
+
+	var c cfg
+	a.EqualError(Load(&c, ""), `env: cannot load environment config: `+
+		`"TLS_CERT": variable missing, required if TLS_ENABLED`)
+
+	a.EqualError(New(ExtendedBool()).Load(&c, ""), `env: cannot load environment config: `+
+		`"TLS_CERT": variable missing, required if TLS_ENABLED, `+
+		`"TRACE": variable missing, required if DEBUG`)
+}
+
+func TestRulesErrors(t *testing.T) {
+	a := assert.New(t)
+
+	var c struct {
+		Labels map[string]string `env:"LABELS_,requiredIf=X"`
+		Nested struct {
+			User string `env:"USER,excludes=X"`
+		} `env:"NESTED_,excludes=X"`
+		Group struct {
+			User string `env:"USER,oneOf=b"`
+		} `env:"GROUP_,oneOf=a"`
+		Empty string `env:"EMPTY,oneOf="`
+	}
+	err := Load(&c, "")
+	a.EqualError(err, `env: cannot load environment config: `+
+		`"Labels": requiredIf option is supported only for variables and structs, `+
+		`"Nested": excludes option is supported only for variables, `+
+		`"User": oneOf group "b" inside group "a", `+
+		`"Empty": empty oneOf`)
+}
+
+func TestRulesUsage(t *testing.T) {
+	a := assert.New(t)
+
+	var buf bytes.Buffer
+	a.NoError(New().Usage(&buf, (*rulesConfig)(nil), "APP_"))
+	a.Equal(`VARIABLE         TYPE    DESCRIPTION
+APP_TLS_ENABLED  bool
+APP_TLS_CERT     string  (required if APP_TLS_ENABLED)
+APP_MODE         string
+APP_SOCKET       string  (required if APP_MODE=unix)
+APP_TOKEN        string  (excludes APP_PASSWORD, APP_CERT)
+APP_DSN          string  (one of group db)
+APP_DB_HOST      string  (one of group db)
+APP_DB_PORT      int     (one of group db)
+`, buf.String())
+}
@@ -0,0 +1,82 @@
+package env
+
+import (
+	"os"
+	"strings"
+)
+
+// scrubMode tells which variables are unset by the successful loads, see
+// ScrubEnv and ScrubSecrets.
+type scrubMode int
+
+const (
+	scrubNone scrubMode = iota
+	scrubSecrets
+	scrubAll
+)
+
+// ScrubEnv makes the loader unset all the variables used by each successful
+// load from the environment of the process, so that they're neither inherited
+// by the child processes nor found in crash dumps. The variables are unset by
+// their names, including the deprecated ones (see the alias tag option),
+// whichever source their values come from. The variables read by the env://
+// references are unset as well, see EnvResolver. A failed load doesn't unset
+// anything, so that it can be retried.
+//
+// The later loads don't find the unset variables in the environment, which
+// includes the reloads, see NewReloader.
+func ScrubEnv() Option {
+	return func(l *Loader) {
+		l.scrub = scrubAll
+	}
+}
+
+// ScrubSecrets is the same as ScrubEnv but only the secret variables are unset,
+// i.e. the variables of the fields with the secret tag option and the ones
+// whose values were resolved from references, see ResolveScheme.
+func ScrubSecrets() Option {
+	return func(l *Loader) {
+		l.scrub = scrubSecrets
+	}
+}
+
+// scrubEnv unsets the variables of the report from the environment, as set by
+// ScrubEnv or ScrubSecrets.
+func (s *loadState) scrubEnv() {
+	if s.scrub == scrubNone {
+		return
+	}
+	for _, p := range s.report.Vars {
+		if s.scrub == scrubAll || p.Secret {
+			for _, name := range s.report.names(p.Name) {
+				os.Unsetenv(name)
+			}
+		}
+	}
+}
+
+// Environ returns the environment of the process in the same form as
+// os.Environ, but without the secret variables of the report, under any of
+// their names, and the variables read by their env:// references. It's meant for the child processes which must not inherit the
+// secrets:
+//
+//	cmd := exec.Command("backup")
+//	cmd.Env = report.Environ()
+func (r *Report) Environ() []string {
+	secret := make(map[string]bool)
+	for _, p := range r.Vars {
+		if p.Secret {
+			for _, name := range r.names(p.Name) {
+				secret[name] = true
+			}
+		}
+	}
+	var env []string
+	for _, ev := range os.Environ() {
+		name, _, _ := strings.Cut(ev, "=")
+		if !secret[name] {
+			env = append(env, ev)
+		}
+	}
+	return env
+}
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type scrubConfig struct {
+	User     string            `env:"USER"`
+	Password string            `env:"PASSWORD,secret"`
+	Labels   map[string]string `env:"LABEL_"`
+}
+
+func setScrubEnv() {
+	os.Clearenv()
+	os.Setenv("APP_USER", "joe")
+	os.Setenv("APP_PASSWORD", "hunter2")
+	os.Setenv("APP_LABEL_team", "core")
+	os.Setenv("OTHER", "x")
+}
+
+func TestScrubEnv(t *testing.T) {
+	a := assert.New(t)
+
+	setScrubEnv()
+	var c scrubConfig
+	a.NoError(New(ScrubEnv()).Load(&c, "APP_"))
+	a.Equal(scrubConfig{"joe", "hunter2", map[string]string{"team": "core"}}, c)
+	a.Equal([]string{"OTHER=x"}, os.Environ())
+
+	setScrubEnv()
+	a.NoError(New(ScrubSecrets()).Load(&c, "APP_"))
+	a.ElementsMatch([]string{"APP_USER=joe", "APP_LABEL_team=core", "OTHER=x"}, os.Environ())
+
+	// A failed load doesn't unset anything.
+	setScrubEnv()
+	os.Unsetenv("APP_USER")
+	a.Error(New(ScrubEnv()).Load(&c, "APP_"))
+	a.ElementsMatch([]string{"APP_PASSWORD=hunter2", "APP_LABEL_team=core", "OTHER=x"}, os.Environ())
+}
+
+func TestReportEnviron(t *testing.T) {
+	a := assert.New(t)
+
+	setScrubEnv()
+	var c scrubConfig
+	report, err := Explain(&c, "APP_")
+	a.NoError(err)
+	a.True(report.Vars[1].Secret)
+	a.ElementsMatch([]string{"APP_USER=joe", "APP_LABEL_team=core", "OTHER=x"}, report.Environ())
+	a.Len(os.Environ(), 4)
+}
+
+func TestScrubAliases(t *testing.T) {
+	a := assert.New(t)
+
+	var c struct {
+		Login string `env:"S_USER,alias=S_LOGIN,secret"`
+	}
+
+	os.Clearenv()
+	os.Setenv("S_USER", "u")
+	os.Setenv("S_LOGIN", "u")
+	os.Setenv("OTHER", "x")
+	report, err := Explain(&c, "")
+	a.NoError(err)
+	a.Equal([]string{"OTHER=x"}, report.Environ())
+	a.NoError(New(ScrubSecrets()).Load(&c, ""))
+	a.Equal([]string{"OTHER=x"}, os.Environ())
+
+	// Loaded by the deprecated name.
+	os.Setenv("S_LOGIN", "u")
+	a.NoError(New(ScrubEnv()).Load(&c, ""))
+	a.Equal([]string{"OTHER=x"}, os.Environ())
+}
+
+func TestScrubEnvRef(t *testing.T) {
+	a := assert.New(t)
+
+	var c struct {
+		Password string `env:"APP_PASSWORD"`
+	}
+
+	os.Clearenv()
+	os.Setenv("APP_PASSWORD", "env://REAL_SECRET")
+	os.Setenv("REAL_SECRET", "hunter2")
+	os.Setenv("OTHER", "x")
+	report, err := New(DefaultResolvers()).Explain(&c, "")
+	a.NoError(err)
+	a.Equal("hunter2", c.Password)
+	a.Equal([]string{"OTHER=x"}, report.Environ())
+	a.NoError(New(DefaultResolvers(), ScrubSecrets()).Load(&c, ""))
+	a.Equal([]string{"OTHER=x"}, os.Environ())
+}
@@ -0,0 +1,170 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type Common struct {
+	Name string `env:"NAME"`
+}
+
+type tlsSection struct {
+	Cert string `env:"CERT"`
+	Key  string `env:"KEY"`
+}
+
+type sectionConfig struct {
+	*Common
+	DB *struct {
+		User string `env:"USER"`
+	} `env:"DB_"`
+	TLS *tlsSection `env:"TLS_,optional"`
+}
+
+func TestStructPointers(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_NAME", "app")
+	os.Setenv("APP_DB_USER", "joe")
+
+	var c sectionConfig
+	a.NoError(Load(&c, "APP_"))
+	a.Equal("app", c.Name)
+	a.Equal("joe", c.DB.User)
+	a.Nil(c.TLS)
+
+	// The pointers of the destination are not written through.
+	db := c.DB
+	os.Setenv("APP_DB_USER", "bob")
+	os.Setenv("APP_TLS_CERT", "cert")
+	a.Error(Load(&c, "APP_"))
+	a.Same(db, c.DB)
+	a.Equal("joe", db.User)
+	a.Nil(c.TLS)
+
+	os.Setenv("APP_TLS_KEY", "key")
+	a.NoError(Load(&c, "APP_"))
+	a.Equal("joe", db.User)
+	a.Equal("bob", c.DB.User)
+	a.Equal(&tlsSection{"cert", "key"}, c.TLS)
+
+	// An absent section is reset to nil.
+	tls := c.TLS
+	os.Unsetenv("APP_TLS_CERT")
+	os.Unsetenv("APP_TLS_KEY")
+	a.NoError(Load(&c, "APP_"))
+	a.Nil(c.TLS)
+	a.Equal(&tlsSection{"cert", "key"}, tls)
+
+	c.TLS = tls
+	a.NoError(New(PartialFill()).Load(&c, "APP_"))
+	a.Nil(c.TLS)
+}
+
+func TestOptionalSection(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_NAME", "app")
+	os.Setenv("APP_DB_USER", "joe")
+	os.Setenv("APP_TLS_KEY", "key")
+
+	var c sectionConfig
+	err := Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: "APP_TLS_CERT": variable missing`)
+
+	var buf bytes.Buffer
+	a.NoError(New().Usage(&buf, &c, "APP_"))
+	a.Equal(`VARIABLE      TYPE    DESCRIPTION
+APP_NAME      string
+APP_DB_USER   string
+APP_TLS_CERT  string  (if any APP_TLS_* is set)
+APP_TLS_KEY   string  (if any APP_TLS_* is set)
+`, buf.String())
+
+	var bad struct {
+		Common Common `env:"COMMON_,optional"`
+		Port   *int   `env:"PORT,optional"`
+	}
+	err = Load(&bad, "")
+	a.EqualError(err, `env: cannot load environment config: `+
+		`"Common": optional option is supported only for pointers to structs, `+
+		`"Port": optional option is supported only for pointers to structs`)
+}
+
+type embeddedState struct {
+	count int
+	next  *embeddedState
+}
+
+type embeddedTLS struct {
+	Cert string `env:"CERT"`
+}
+
+func TestEmbeddedUnexportedPointer(t *testing.T) {
+	a := assert.New(t)
+
+	os.Clearenv()
+	os.Setenv("APP_NAME", "app")
+
+	// The embedded pointers to unexported structs without variables are
+	// skipped.
+	var c struct {
+		*embeddedState
+		Name string `env:"NAME"`
+	}
+	a.NoError(Load(&c, "APP_"))
+	a.Equal("app", c.Name)
+	a.Nil(c.embeddedState)
+
+	var bad struct {
+		*embeddedTLS
+	}
+	err := Load(&bad, "APP_")
+	a.EqualError(err, `env: cannot load environment config: "embeddedTLS": cannot write unexported field`)
+}
+
+type node struct {
+	Value string `env:"VALUE"`
+	Next  *node  `env:"NEXT_,optional"`
+}
+
+func TestRecursiveType(t *testing.T) {
+	a := assert.New(t)
+
+	var c struct {
+		Root node `env:"ROOT_"`
+	}
+	err := Load(&c, "")
+	a.EqualError(err, `env: cannot load environment config: "ROOT_VALUE": variable missing, "Next": recursive type env.node`)
+}
+
+func TestReloadSection(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		TLS *tlsSection `env:"TLS_,optional"`
+	}
+	vars := map[string]string{"APP_TLS_CERT": "cert", "APP_TLS_KEY": "key"}
+	src := newMapSource(vars, Origin{Source: "test"})
+	r, err := NewReloader[cfg](New(Sources(src)), "APP_", nil)
+	a.NoError(err)
+
+	delete(src, "APP_TLS_CERT")
+	delete(src, "APP_TLS_KEY")
+	var got []Change
+	r.Subscribe(func(old, new *cfg, changes []Change) {
+		got = changes
+	})
+	a.NoError(r.Reload())
+	a.Nil(r.Current().TLS)
+	a.Equal([]Change{
+		{"APP_TLS_CERT", "TLS.Cert", "cert", nil},
+		{"APP_TLS_KEY", "TLS.Key", "key", nil},
+	}, got)
+}
@@ -0,0 +1,33 @@
+package env
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// SlogEvents makes the loader log its events (see OnEvent) by h: the loaded
+// variables at the info level, and the uses of aliases and the unused
+// variables at the warning level. The records have the attributes event,
+// name, field (unless empty), value and origin, e.g.:
+//
+//	level=INFO msg="env: value loaded" event=loaded name=PREFIX_PORT field=Port value=8080 origin=env
+func SlogEvents(h slog.Handler) Option {
+	return OnEvent(func(e Event) {
+		ctx := context.Background()
+		level := slog.LevelInfo
+		if e.Kind == EventAlias || e.Kind == EventUnknown || e.Kind == EventInactive {
+			level = slog.LevelWarn
+		}
+		if !h.Enabled(ctx, level) {
+			return
+		}
+		r := slog.NewRecord(time.Now(), level, "env: "+e.Message, 0)
+		r.AddAttrs(slog.String("event", e.Kind.String()), slog.String("name", e.Name))
+		if e.Field != "" {
+			r.AddAttrs(slog.String("field", e.Field))
+		}
+		r.AddAttrs(slog.String("value", e.Value), slog.String("origin", e.Origin.String()))
+		h.Handle(ctx, r) //nolint:errcheck // There's nowhere to report the error.
+	})
+}
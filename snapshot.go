@@ -0,0 +1,118 @@
+package env
+
+import (
+	"os"
+	"sort"
+	"strings"
+)
+
+// snapshot is a consistent copy of the variables of a source taken at the
+// start of a load, so that the source is read just once and all the variables
+// come from the same point in time. The variables are sorted by their names
+// for prefix lookups.
+type snapshot struct {
+	names []string
+	vars  []Var
+}
+
+// snapshotter is implemented by the sources which can copy all of their
+// variables at once.
+type snapshotter interface {
+	snapshot() *snapshot
+}
+
+func takeSnapshot(src Source) *snapshot {
+	if s, ok := src.(snapshotter); ok {
+		return s.snapshot()
+	}
+	names := src.Names()
+	vars := make(mapSource, len(names))
+	for _, name := range names {
+		if v, ok := src.Lookup(name); ok {
+			vars[name] = v
+		}
+	}
+	return vars.snapshot()
+}
+
+func (s *snapshot) Lookup(name string) (Var, bool) {
+	i := sort.SearchStrings(s.names, name)
+	if i < len(s.names) && s.names[i] == name {
+		return s.vars[i], true
+	}
+	return Var{}, false
+}
+
+func (s *snapshot) Names() []string {
+	return append([]string(nil), s.names...)
+}
+
+// prefixed returns the sorted names starting with prefix.
+func (s *snapshot) prefixed(prefix string) []string {
+	i := sort.SearchStrings(s.names, prefix)
+	j := i
+	for j < len(s.names) && strings.HasPrefix(s.names[j], prefix) {
+		j++
+	}
+	return s.names[i:j:j]
+}
+
+func (s *snapshot) snapshot() *snapshot {
+	return s
+}
+
+func (osEnv) snapshot() *snapshot {
+	env := os.Environ()
+	names := make([]string, len(env))
+	values := make([]string, len(env))
+	order := make([]int, len(env))
+	for i, ev := range env {
+		names[i], values[i], _ = strings.Cut(ev, "=")
+		order[i] = i
+	}
+	// The first of the duplicate variables wins, as in os.LookupEnv.
+	sort.SliceStable(order, func(i, j int) bool {
+		return names[order[i]] < names[order[j]]
+	})
+	s := &snapshot{
+		names: make([]string, 0, len(env)),
+		vars:  make([]Var, 0, len(env)),
+	}
+	o := Origin{Source: "env"}
+	for _, i := range order {
+		if n := len(s.names); n > 0 && s.names[n-1] == names[i] {
+			continue
+		}
+		s.names = append(s.names, names[i])
+		s.vars = append(s.vars, Var{values[i], o})
+	}
+	return s
+}
+
+func (m mapSource) snapshot() *snapshot {
+	s := &snapshot{
+		names: m.Names(),
+		vars:  make([]Var, len(m)),
+	}
+	for i, name := range s.names {
+		s.vars[i] = m[name]
+	}
+	return s
+}
+
+func (ls layered) snapshot() *snapshot {
+	vars := make(mapSource)
+	for i := len(ls) - 1; i >= 0; i-- {
+		s := takeSnapshot(ls[i])
+		for j, name := range s.names {
+			vars[name] = s.vars[j]
+		}
+	}
+	return vars.snapshot()
+}
+
+func (fs *fileSource) snapshot() *snapshot {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.vars.snapshot()
+}
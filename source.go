@@ -0,0 +1,343 @@
+package env
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Source provides the variables to a Loader. The default source is the
+// environment of the process, see OSEnv.
+type Source interface {
+	// Lookup returns the variable called name, if it exists.
+	Lookup(name string) (Var, bool)
+
+	// Names returns the names of all the variables in the source.
+	Names() []string
+}
+
+// Var is a value of a variable together with its origin.
+type Var struct {
+	Value  string
+	Origin Origin
+}
+
+// Origin tells where a value of a variable came from.
+type Origin struct {
+	// Source is the name of the source, e.g. "env" or "dotenv".
+	Source string
+
+	// File and Line locate the value in a file, if the source reads
+	// files. Line is 0 when the whole file is the value.
+	File string
+	Line int
+
+	// Default is true if the value comes from a source of defaults, see
+	// Defaults.
+	Default bool
+
+	// Ref is the reference the value was resolved from, see
+	// ResolveScheme. The resolved values are secret.
+	Ref string
+}
+
+func (o Origin) String() string {
+	s := o.Source
+	switch {
+	case o.File != "" && o.Line > 0:
+		s = fmt.Sprintf("%s %s:%d", o.Source, o.File, o.Line)
+	case o.File != "":
+		s = fmt.Sprintf("%s %s", o.Source, o.File)
+	}
+	if o.Ref != "" {
+		s += " -> " + o.Ref
+	}
+	return s
+}
+
+// Sources makes the loader read the variables from srcs instead of the
+// environment of the process. If the same variable is provided by more than
+// one source, the first one wins, see Layered.
+func Sources(srcs ...Source) Option {
+	return func(l *Loader) {
+		if len(srcs) == 1 {
+			l.src = srcs[0]
+		} else {
+			l.src = Layered(srcs...)
+		}
+	}
+}
+
+type osEnv struct{}
+
+// OSEnv returns the source reading the environment of the process. The values
+// have the origin "env".
+func OSEnv() Source {
+	return osEnv{}
+}
+
+func (osEnv) Lookup(name string) (Var, bool) {
+	s, ok := os.LookupEnv(name)
+	return Var{s, Origin{Source: "env"}}, ok
+}
+
+func (osEnv) Names() []string {
+	env := os.Environ()
+	names := make([]string, 0, len(env))
+	for _, ev := range env {
+		names = append(names, strings.SplitN(ev, "=", 2)[0])
+	}
+	return names
+}
+
+// mapSource is a static set of variables.
+type mapSource map[string]Var
+
+// Map returns a source holding vars. The values have the origin called name.
+func Map(name string, vars map[string]string) Source {
+	return newMapSource(vars, Origin{Source: name})
+}
+
+// Defaults returns a source holding the default values of variables. It's
+// meant to be the last of the sources so that it's used only for the
+// variables which are not provided by any other source. The values have the
+// origin "defaults".
+func Defaults(vars map[string]string) Source {
+	return newMapSource(vars, Origin{Source: "defaults", Default: true})
+}
+
+func newMapSource(vars map[string]string, o Origin) mapSource {
+	m := make(mapSource, len(vars))
+	for k, v := range vars {
+		m[k] = Var{v, o}
+	}
+	return m
+}
+
+func (m mapSource) Lookup(name string) (Var, bool) {
+	v, ok := m[name]
+	return v, ok
+}
+
+func (m mapSource) Names() []string {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type layered []Source
+
+// Layered combines srcs into a single source. A variable is looked up in srcs
+// in order and the first one providing it wins. For example,
+//
+//	Layered(OSEnv(), dotenv, Defaults(defaults))
+//
+// prefers the environment to the dotenv file, and both of them to defaults.
+func Layered(srcs ...Source) Source {
+	return layered(srcs)
+}
+
+func (ls layered) Lookup(name string) (Var, bool) {
+	for _, src := range ls {
+		if v, ok := src.Lookup(name); ok {
+			return v, true
+		}
+	}
+	return Var{}, false
+}
+
+func (ls layered) Names() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, src := range ls {
+		for _, name := range src.Names() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Refresher is implemented by the sources which read the variables ahead of
+// time, e.g. from files. Such sources don't see the changes of the files
+// until they are refreshed, see Reloader.
+type Refresher interface {
+	// Refresh reads the variables again and reports whether they have
+	// changed.
+	Refresh() (bool, error)
+}
+
+// refresh refreshes all srcs implementing Refresher and reports whether any
+// of them has changed. All of them are refreshed even if some fail.
+func refresh(srcs ...Source) (bool, error) {
+	var changed bool
+	var errs []error
+	for _, src := range srcs {
+		r, ok := src.(Refresher)
+		if !ok {
+			continue
+		}
+		c, err := r.Refresh()
+		if err != nil {
+			errs = append(errs, err)
+		}
+		changed = changed || c
+	}
+	if len(errs) > 0 {
+		return changed, &loadError{errs}
+	}
+	return changed, nil
+}
+
+func (ls layered) Refresh() (bool, error) {
+	return refresh(ls...)
+}
+
+// fileSource is a source holding the variables read from files. It reads them
+// again when it's refreshed.
+type fileSource struct {
+	read func() (mapSource, error)
+
+	mu   sync.RWMutex
+	vars mapSource
+}
+
+func newFileSource(read func() (mapSource, error)) (*fileSource, error) {
+	fs := &fileSource{read: read}
+	if _, err := fs.Refresh(); err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+func (fs *fileSource) Lookup(name string) (Var, bool) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.vars.Lookup(name)
+}
+
+func (fs *fileSource) Names() []string {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.vars.Names()
+}
+
+func (fs *fileSource) Refresh() (bool, error) {
+	vars, err := fs.read()
+	if err != nil {
+		return false, err
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	changed := !reflect.DeepEqual(fs.vars, vars)
+	fs.vars = vars
+	return changed, nil
+}
+
+// Dotenv returns a source holding the variables from the dotenv file at path.
+// The file is read by Dotenv and then again whenever the source is refreshed,
+// see Refresher. Each line of the file holds a single NAME=value assignment,
+// optionally prefixed by "export". Empty lines and lines starting with # are
+// ignored. The values may be quoted by double quotes (in which case Go escape
+// sequences are expanded) or single quotes (in which case the value is taken
+// literally). Unquoted values end at " #", which starts a comment. The values
+// have the origin "dotenv" with the file and line.
+func Dotenv(path string) (Source, error) {
+	fs, err := newFileSource(func() (mapSource, error) {
+		return readDotenv(path)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return fs, nil
+}
+
+// readDotenv reads the dotenv file at path, see Dotenv.
+func readDotenv(path string) (mapSource, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	m := make(mapSource)
+	sc := bufio.NewScanner(f)
+	for lineno := 1; sc.Scan(); lineno++ {
+		name, value, ok, err := parseDotenvLine(sc.Text())
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineno, err)
+		}
+		if ok {
+			o := Origin{Source: "dotenv", File: path, Line: lineno}
+			m[name] = Var{value, o}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// parseDotenvLine parses a single line of a dotenv file. If the line holds no
+// variable, ok is false.
+func parseDotenvLine(line string) (name, value string, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || line[0] == '#' {
+		return "", "", false, nil
+	}
+	line = strings.TrimPrefix(line, "export ")
+	i := strings.IndexByte(line, '=')
+	if i < 0 {
+		return "", "", false, fmt.Errorf("missing =")
+	}
+	name = strings.TrimSpace(line[:i])
+	if name == "" {
+		return "", "", false, fmt.Errorf("missing variable name")
+	}
+	value = strings.TrimSpace(line[i+1:])
+	if value == "" || (value[0] != '"' && value[0] != '\'') {
+		if i := strings.Index(value, " #"); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+		return name, value, true, nil
+	}
+	end := closingQuote(value)
+	if end < 0 {
+		return "", "", false, fmt.Errorf("unbalanced quotes")
+	}
+	if rest := strings.TrimSpace(value[end+1:]); rest != "" && rest[0] != '#' {
+		return "", "", false, fmt.Errorf("unexpected %q after quotes", rest)
+	}
+	if value[0] == '\'' {
+		return name, value[1:end], true, nil
+	}
+	if value, err = strconv.Unquote(value[:end+1]); err != nil {
+		return "", "", false, fmt.Errorf("invalid quoted value")
+	}
+	return name, value, true, nil
+}
+
+// closingQuote returns the index of the quote closing the one which s starts
+// with, or -1 if there's none. Double quotes may be escaped by backslash.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch {
+		case s[0] == '"' && s[i] == '\\':
+			i++
+		case s[i] == s[0]:
+			return i
+		}
+	}
+	return -1
+}
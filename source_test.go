@@ -0,0 +1,154 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDotenv(t *testing.T) {
+	a := assert.New(t)
+
+	src, err := Dotenv("testdata/test.env")
+	a.NoError(err)
+	a.Equal([]string{
+		"PREFIX_HOST",
+		"PREFIX_LABEL_team",
+		"PREFIX_NAME",
+		"PREFIX_PATTERN",
+		"PREFIX_PORT",
+		"PREFIX_TIMEOUT",
+	}, src.Names())
+
+	samples := map[string]string{
+		"PREFIX_HOST":       "db.example.org",
+		"PREFIX_PORT":       "5432",
+		"PREFIX_NAME":       `app "main"`,
+		"PREFIX_PATTERN":    `^\d+$`,
+		"PREFIX_LABEL_team": "core",
+	}
+	for name, ref := range samples {
+		v, ok := src.Lookup(name)
+		a.True(ok)
+		a.Equal(ref, v.Value)
+		a.Equal("dotenv", v.Origin.Source)
+		a.Equal("testdata/test.env", v.Origin.File)
+	}
+	v, _ := src.Lookup("PREFIX_PORT")
+	a.Equal(4, v.Origin.Line)
+}
+
+func TestDotenvBad(t *testing.T) {
+	a := assert.New(t)
+
+	samples := []string{
+		"FOO",
+		"=foo",
+		`FOO="foo`,
+		`FOO='foo`,
+	}
+	dir := t.TempDir()
+	for _, s := range samples {
+		path := filepath.Join(dir, ".env")
+		a.NoError(os.WriteFile(path, []byte("\n"+s+"\n"), 0600))
+
+		_, err := Dotenv(path)
+		a.Error(err, "parsing %q should fail", s)
+		a.Contains(err.Error(), ".env:2:")
+	}
+
+	_, err := Dotenv(filepath.Join(dir, "missing"))
+	a.Error(err)
+}
+
+func TestLayered(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Host   string            `env:"HOST"`
+		Port   int               `env:"PORT"`
+		User   string            `env:"USER"`
+		Labels map[string]string `env:"LABEL_"`
+	}
+
+	dotenv, err := Dotenv("testdata/test.env")
+	a.NoError(err)
+
+	os.Clearenv()
+	os.Setenv("PREFIX_HOST", "localhost")
+	os.Setenv("PREFIX_LABEL_env", "dev")
+
+	l := New(Sources(
+		OSEnv(),
+		dotenv,
+		Defaults(map[string]string{
+			"PREFIX_PORT": "1",
+			"PREFIX_USER": "nobody",
+		}),
+	))
+
+	var c cfg
+	report, err := l.Explain(&c, "PREFIX_")
+	a.NoError(err)
+	a.Equal(cfg{
+		Host:   "localhost",
+		Port:   5432,
+		User:   "nobody",
+		Labels: map[string]string{"env": "dev", "team": "core"},
+	}, c)
+
+	a.Equal([]Provenance{
+		{"PREFIX_HOST", "Host", Origin{Source: "env"}, false},
+		{"PREFIX_PORT", "Port", Origin{Source: "dotenv", File: "testdata/test.env", Line: 4}, false},
+		{"PREFIX_USER", "User", Origin{Source: "defaults", Default: true}, false},
+		{"PREFIX_LABEL_env", "Labels", Origin{Source: "env"}, false},
+		{"PREFIX_LABEL_team", "Labels", Origin{Source: "dotenv", File: "testdata/test.env", Line: 7}, false},
+	}, report.Vars)
+
+	o, ok := report.Origin("PREFIX_USER")
+	a.True(ok)
+	a.True(o.Default)
+
+	var buf bytes.Buffer
+	a.NoError(report.Write(&buf))
+	a.Equal(`VARIABLE           FIELD   ORIGIN
+PREFIX_HOST        Host    env
+PREFIX_PORT        Port    dotenv testdata/test.env:4
+PREFIX_USER        User    defaults
+PREFIX_LABEL_env   Labels  env
+PREFIX_LABEL_team  Labels  dotenv testdata/test.env:7
+`, buf.String())
+}
+
+func TestLayeredError(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Timeout int `env:"TIMEOUT"`
+	}
+
+	dotenv, err := Dotenv("testdata/test.env")
+	a.NoError(err)
+
+	var c cfg
+	err = New(Sources(dotenv)).Load(&c, "PREFIX_")
+	a.EqualError(err, `env: cannot load environment config: `+
+		`"PREFIX_TIMEOUT" (dotenv testdata/test.env:8): `+
+		`cannot parse "never" as int: `+
+		`strconv.Atoi: parsing "never": invalid syntax`)
+}
+
+func TestMapSource(t *testing.T) {
+	a := assert.New(t)
+
+	src := Map("test", map[string]string{"B": "b", "A": "a"})
+	a.Equal([]string{"A", "B"}, src.Names())
+	v, ok := src.Lookup("A")
+	a.True(ok)
+	a.Equal(Var{"a", Origin{Source: "test"}}, v)
+	_, ok = src.Lookup("C")
+	a.False(ok)
+}
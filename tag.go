@@ -0,0 +1,99 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+)
+
+// tagOptions are the options which may follow the variable name in the env
+// tag, separated by commas, e.g. `env:"KEY,encoding=hex,alias=OLD_KEY"`.
+type tagOptions struct {
+	// encoding is the encoding of []byte and [N]byte values, see
+	// byteEncodings.
+	encoding string
+
+	// autoBase enables detection of the base of integers, see AutoIntBase.
+	autoBase bool
+
+	// extBool enables the extended boolean vocabulary, see ExtendedBool.
+	extBool bool
+
+	// optional leaves a pointer to a struct nil when no variable with its
+	// prefix is set.
+	optional bool
+
+	// secret hides the value in the errors and events.
+	secret bool
+
+	// kind is the name of the discriminator variable of variants, without
+	// the name of the field, see RegisterVariants.
+	kind string
+
+	// requiredIf, excludes and oneOf are the conditional requirements of
+	// the field, see rules.
+	requiredIf string
+	excludes   []string
+	oneOf      string
+
+	// aliases are the deprecated names of the variable, without the
+	// prefix.
+	aliases []string
+
+	// all are all the options as they are written in the tag and unknown
+	// are those not recognized by parseTag, which are allowed only for the
+	// EnvUnmarshalers.
+	all, unknown []string
+}
+
+// valueOptions are the tag options which must have a value.
+var valueOptions = map[string]bool{
+	"kind":       true,
+	"requiredIf": true,
+	"excludes":   true,
+	"oneOf":      true,
+	"alias":      true,
+}
+
+// parseTag splits the env tag into the variable name and its options.
+func parseTag(tag string) (string, tagOptions, error) {
+	var opts tagOptions
+	spl := strings.Split(tag, ",")
+	opts.all = spl[1:]
+	for _, opt := range spl[1:] {
+		key, val := opt, ""
+		if i := strings.IndexByte(opt, '='); i >= 0 {
+			key, val = opt[:i], opt[i+1:]
+		}
+		if val == "" && valueOptions[key] {
+			return "", opts, fmt.Errorf("empty %s", key)
+		}
+		switch key {
+		case "encoding":
+			if _, ok := byteEncodings[val]; !ok {
+				return "", opts, fmt.Errorf("unknown encoding %q", val)
+			}
+			opts.encoding = val
+		case "autobase":
+			opts.autoBase = true
+		case "extbool":
+			opts.extBool = true
+		case "optional":
+			opts.optional = true
+		case "secret":
+			opts.secret = true
+		case "kind":
+			opts.kind = val
+		case "requiredIf":
+			opts.requiredIf = val
+		case "excludes":
+			opts.excludes = append(opts.excludes, val)
+		case "oneOf":
+			opts.oneOf = val
+		case "alias":
+			opts.aliases = append(opts.aliases, val)
+		default:
+			opts.unknown = append(opts.unknown, key)
+		}
+	}
+	return spl[0], opts, nil
+}
@@ -0,0 +1,96 @@
+package env
+
+import (
+	"context"
+	"reflect"
+)
+
+// EnvUnmarshaler is implemented by the types which load themselves, e.g.
+// from more than one variable. The fields of such types are neither parsed
+// nor recursed into; UnmarshalEnv is called instead. Its error is included in
+// the error of the load. For example:
+//
+//	type TLSFiles struct {
+//		Cert, Key []byte
+//	}
+//
+//	func (f *TLSFiles) UnmarshalEnv(ctx env.LoadContext) error {
+//		cert, _ := ctx.Lookup(ctx.Name + "CERT")
+//		key, _ := ctx.Lookup(ctx.Name + "KEY")
+//		...
+//	}
+//
+//	type config struct {
+//		TLS TLSFiles `env:"TLS_"` // loaded from PREFIX_TLS_CERT and PREFIX_TLS_KEY
+//	}
+//
+// The parsers registered for the type take precedence over UnmarshalEnv.
+type EnvUnmarshaler interface {
+	UnmarshalEnv(ctx LoadContext) error
+}
+
+// LoadContext is passed to EnvUnmarshaler.
+type LoadContext struct {
+	// Context is the context of the load, see Loader.LoadContext.
+	Context context.Context
+
+	// Name is the name of the field from the env tag, including the prefix.
+	// The types which load more variables use it as the prefix of their
+	// names.
+	Name string
+
+	// Field is the path to the struct field, e.g. "Server.TLS".
+	Field string
+
+	// Tag is the whole struct tag of the field.
+	Tag reflect.StructTag
+
+	// Options are the options following the name in the env tag. Unlike
+	// the other fields, the EnvUnmarshalers may have options unknown to the
+	// loader.
+	Options []string
+
+	s      *loadState
+	secret bool
+
+	// errs are the errors of the references looked up, see Lookup.
+	errs *[]error
+}
+
+// Lookup returns the variable called name from the sources of the load. The
+// variables found are included in the report of the load (see Explain) and
+// reported to the event hooks (see OnEvent). The references are resolved (see
+// ResolveScheme); if one cannot be resolved, the variable is reported missing
+// and the error is included in the error of the load.
+func (ctx LoadContext) Lookup(name string) (Var, bool) {
+	v, ok, err := ctx.s.lookupVar(name)
+	if err != nil {
+		*ctx.errs = append(*ctx.errs, err)
+		return Var{}, false
+	}
+	if ok {
+		ctx.s.record(name, ctx.Field, v, ctx.secret)
+	}
+	return v, ok
+}
+
+var envUnmarshalerType = reflect.TypeOf((*EnvUnmarshaler)(nil)).Elem()
+
+// isEnvUnmarshaler tells whether rt or a pointer to it implements
+// EnvUnmarshaler.
+func isEnvUnmarshaler(rt reflect.Type) bool {
+	return rt.Implements(envUnmarshalerType) || reflect.PtrTo(rt).Implements(envUnmarshalerType)
+}
+
+// envUnmarshaler returns rv as an EnvUnmarshaler, if it is one.
+func envUnmarshaler(rv reflect.Value) EnvUnmarshaler {
+	if rv.CanAddr() {
+		if eu, ok := rv.Addr().Interface().(EnvUnmarshaler); ok {
+			return eu
+		}
+	}
+	if eu, ok := rv.Interface().(EnvUnmarshaler); ok {
+		return eu
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package env
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type tlsFiles struct {
+	Cert, Key string
+	Options   []string
+}
+
+func (f *tlsFiles) UnmarshalEnv(ctx LoadContext) error {
+	cert, ok := ctx.Lookup(ctx.Name + "CERT")
+	if !ok {
+		return &VarError{Name: ctx.Name + "CERT", Err: errors.New("variable missing")}
+	}
+	key, ok := ctx.Lookup(ctx.Name + "KEY")
+	if !ok {
+		return errors.New("key missing")
+	}
+	if !strings.HasPrefix(key.Value, "-----") {
+		return &VarError{Name: ctx.Name + "KEY", Origin: &key.Origin, Err: errors.New("not a PEM key")}
+	}
+	*f = tlsFiles{cert.Value, key.Value, ctx.Options}
+	return nil
+}
+
+func TestEnvUnmarshaler(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		TLS      tlsFiles  `env:"TLS_,strict"`
+		AdminTLS *tlsFiles `env:"ADMIN_TLS_"`
+		Port     int       `env:"PORT"`
+	}
+
+	os.Clearenv()
+	os.Setenv("APP_TLS_CERT", "cert")
+	os.Setenv("APP_TLS_KEY", "-----key")
+	os.Setenv("APP_ADMIN_TLS_CERT", "admin-cert")
+	os.Setenv("APP_ADMIN_TLS_KEY", "-----admin-key")
+	os.Setenv("APP_PORT", "80")
+
+	var c cfg
+	report, err := Explain(&c, "APP_")
+	a.NoError(err)
+	a.Equal(tlsFiles{"cert", "-----key", []string{"strict"}}, c.TLS)
+	a.Equal(&tlsFiles{"admin-cert", "-----admin-key", []string{}}, c.AdminTLS)
+	a.Equal([]Provenance{
+		{"APP_TLS_CERT", "TLS", Origin{Source: "env"}, false},
+		{"APP_TLS_KEY", "TLS", Origin{Source: "env"}, false},
+		{"APP_ADMIN_TLS_CERT", "AdminTLS", Origin{Source: "env"}, false},
+		{"APP_ADMIN_TLS_KEY", "AdminTLS", Origin{Source: "env"}, false},
+		{"APP_PORT", "Port", Origin{Source: "env"}, false},
+	}, report.Vars)
+
+	os.Unsetenv("APP_TLS_CERT")
+	os.Setenv("APP_ADMIN_TLS_KEY", "admin-key")
+	os.Unsetenv("APP_PORT")
+	c = cfg{}
+	err = Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: `+
+		`"APP_TLS_CERT": variable missing, `+
+		`"APP_ADMIN_TLS_KEY" (env): not a PEM key, `+
+		`"APP_PORT": variable missing`)
+	a.Equal(cfg{}, c)
+
+	os.Unsetenv("APP_ADMIN_TLS_KEY")
+	err = Load(&c, "APP_")
+	a.Contains(err.Error(), `"APP_ADMIN_TLS_": key missing`)
+
+	// The unknown tag options are allowed only for EnvUnmarshalers.
+	var bad struct {
+		Port int `env:"PORT,strict"`
+	}
+	a.EqualError(Load(&bad, "APP_"), `env: cannot load environment config: "Port": unknown tag option "strict"`)
+}
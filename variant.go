@@ -0,0 +1,166 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// defaultKind is the name of the discriminator variable, without the name of
+// the field, see RegisterVariants.
+const defaultKind = "KIND"
+
+// variants are the variants of an interface type, see RegisterVariants.
+type variants struct {
+	// kinds are the sorted values of the discriminator.
+	kinds []string
+
+	// types are the variant types by the values of the discriminator.
+	types map[string]reflect.Type
+}
+
+// RegisterVariants registers the variants of an interface type T, given as
+// a map[string]T from the values of the discriminator variable to values of
+// the variant types. A field of type T is then loaded from the variant
+// selected by the discriminator, e.g.:
+//
+//	l.RegisterVariants(map[string]Storage{
+//		"s3":  (*S3Storage)(nil),
+//		"gcs": (*GCSStorage)(nil),
+//	})
+//
+//	type config struct {
+//		Storage Storage `env:"STORAGE_"`
+//	}
+//
+// With PREFIX_STORAGE_KIND=s3, Storage is set to a new *S3Storage loaded with
+// the prefix PREFIX_STORAGE_S3_. Only the variables of the selected variant
+// are required, the ones of the other variants are ignored (see Strict). The
+// discriminator may be given a different name by the kind tag option, e.g.
+// `env:"STORAGE_,kind=TYPE"`.
+//
+// The variant types must be structs or pointers to structs. RegisterVariants
+// panics if values is not a map with string keys and interface values, or
+// some of the values are nil.
+func (l *Loader) RegisterVariants(values interface{}) {
+	rv := reflect.ValueOf(values)
+	rt := rv.Type()
+	if rv.Kind() != reflect.Map || rt.Key().Kind() != reflect.String || rt.Elem().Kind() != reflect.Interface {
+		panic(fmt.Sprintf("env: variants must be map[string]T with interface T, not %T", values))
+	}
+	vs := &variants{types: make(map[string]reflect.Type, rv.Len())}
+	iter := rv.MapRange()
+	for iter.Next() {
+		kind := iter.Key().String()
+		v := iter.Value()
+		if v.IsNil() {
+			panic(fmt.Sprintf("env: variant %q is nil", kind))
+		}
+		vt := v.Elem().Type()
+		st := vt
+		if st.Kind() == reflect.Ptr {
+			st = st.Elem()
+		}
+		if st.Kind() != reflect.Struct {
+			panic(fmt.Sprintf("env: variant %q must be a struct or a struct pointer, not %s", kind, vt))
+		}
+		vs.kinds = append(vs.kinds, kind)
+		vs.types[kind] = vt
+	}
+	sort.Strings(vs.kinds)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.variants == nil {
+		l.variants = make(map[reflect.Type]*variants)
+	}
+	l.variants[rt.Elem()] = vs
+	l.plans = new(sync.Map)
+}
+
+// variantsOf returns the variants of rt, or nil if rt has none.
+func (l *Loader) variantsOf(rt reflect.Type) *variants {
+	if rt.Kind() != reflect.Interface {
+		return nil
+	}
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.variants[rt]
+}
+
+// kindName returns the name of the discriminator variable of f.
+func kindName(f field) string {
+	if f.opts.kind != "" {
+		return f.name + f.opts.kind
+	}
+	return f.name + defaultKind
+}
+
+// variantPrefix returns the prefix of the variables of the variant selected by
+// kind, e.g. GOOGLE_CLOUD_ for google-cloud.
+func variantPrefix(kind string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return '_'
+	}, kind) + "_"
+}
+
+// loadVariant loads the variant of f selected by its discriminator to rv.
+func (s *loadState) loadVariant(f field, rv reflect.Value, vs *variants) error {
+	name := kindName(f)
+	v, ok, err := s.lookupVar(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &VarError{Name: name, Err: ErrMissing}
+	}
+	vt, ok := vs.types[v.Value]
+	if !ok {
+		err := fmt.Errorf("must be one of: %s", strings.Join(vs.kinds, ", "))
+		return &VarError{Name: name, Origin: &v.Origin, Err: err}
+	}
+	s.record(name, f.path, v, false)
+
+	prefix := f.name + variantPrefix(v.Value)
+	nv := reflect.New(vt)
+	errs := s.walk(nv, prefix, s.deref, func(vf field) error {
+		vf.path = f.path + "." + vf.path
+		return s.loadVar(vf)
+	})
+	errs = append(errs, s.checkRules(vt, prefix)...)
+	s.reportUnknown(vt, prefix)
+	s.reportInactive(f, v.Value, vs)
+	if len(errs) > 0 {
+		return &loadError{errs}
+	}
+	rv.Set(nv.Elem())
+	return nil
+}
+
+// reportInactive reports the variables of the variants of f other than the
+// selected one.
+func (s *loadState) reportInactive(f field, selected string, vs *variants) {
+	if !s.reportsUnused() {
+		return
+	}
+	name := kindName(f)
+	prefix := f.name + variantPrefix(selected)
+	for _, kind := range vs.kinds {
+		if kind == selected {
+			continue
+		}
+		for _, vname := range s.namesPrefixed(f.name + variantPrefix(kind)) {
+			if strings.HasPrefix(vname, prefix) {
+				continue
+			}
+			msg := fmt.Sprintf("variant %q is not selected by %s", kind, name)
+			s.unused(EventInactive, vname, f.path, msg)
+		}
+	}
+}
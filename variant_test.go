@@ -0,0 +1,139 @@
+package env
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type storage interface {
+	isStorage()
+}
+
+type s3Storage struct {
+	Bucket string `env:"BUCKET"`
+	Region string `env:"REGION"`
+}
+
+func (*s3Storage) isStorage() {}
+
+type gcsStorage struct {
+	Bucket string `env:"BUCKET"`
+}
+
+func (gcsStorage) isStorage() {}
+
+func newStorageLoader(opts ...Option) *Loader {
+	l := New(opts...)
+	l.RegisterVariants(map[string]storage{
+		"s3":           (*s3Storage)(nil),
+		"google-cloud": gcsStorage{},
+	})
+	return l
+}
+
+func TestVariants(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Storage storage `env:"STORAGE_"`
+		Backup  storage `env:"BACKUP_,kind=TYPE"`
+	}
+
+	l := newStorageLoader()
+
+	os.Clearenv()
+	os.Setenv("APP_STORAGE_KIND", "s3")
+	os.Setenv("APP_STORAGE_S3_BUCKET", "data")
+	os.Setenv("APP_STORAGE_S3_REGION", "eu-west-1")
+	os.Setenv("APP_BACKUP_TYPE", "google-cloud")
+	os.Setenv("APP_BACKUP_GOOGLE_CLOUD_BUCKET", "backup")
+
+	var c cfg
+	report, err := l.Explain(&c, "APP_")
+	a.NoError(err)
+	a.Equal(cfg{&s3Storage{"data", "eu-west-1"}, gcsStorage{"backup"}}, c)
+	a.Equal(Provenance{"APP_STORAGE_S3_BUCKET", "Storage.Bucket", Origin{Source: "env"}, false}, report.Vars[1])
+
+	// Only the variables of the selected variant are required.
+	os.Setenv("APP_STORAGE_KIND", "google-cloud")
+	err = l.Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: "APP_STORAGE_GOOGLE_CLOUD_BUCKET": variable missing`)
+
+	os.Setenv("APP_STORAGE_KIND", "gcs")
+	os.Unsetenv("APP_BACKUP_TYPE")
+	err = l.Load(&c, "APP_")
+	a.EqualError(err, `env: cannot load environment config: "APP_STORAGE_KIND" (env): must be one of: google-cloud, s3, "APP_BACKUP_TYPE": variable missing`)
+	a.Equal(cfg{&s3Storage{"data", "eu-west-1"}, gcsStorage{"backup"}}, c)
+
+	var bad struct {
+		Storage storage `env:"STORAGE_,alias=STORE_"`
+		Port    int     `env:"PORT,kind=TYPE"`
+	}
+	err = l.Load(&bad, "")
+	a.EqualError(err, `env: cannot load environment config: "Storage": aliases are not supported for maps and structs, "Port": kind option used for int, which has no variants`)
+
+	a.Panics(func() {
+		l.RegisterVariants(map[string]storage{"none": nil})
+	})
+	a.Panics(func() {
+		l.RegisterVariants(map[string]s3Storage{"s3": {}})
+	})
+}
+
+func TestVariantsStrict(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Storage storage `env:"STORAGE_"`
+	}
+
+	var warnings []string
+	l := newStorageLoader(Strict(), OnWarning(func(w Warning) {
+		warnings = append(warnings, w.String())
+	}))
+
+	os.Clearenv()
+	os.Setenv("APP_STORAGE_KIND", "google-cloud")
+	os.Setenv("APP_STORAGE_GOOGLE_CLOUD_BUCKET", "data")
+	os.Setenv("APP_STORAGE_GOOGLE_CLOUD_REGION", "eu")
+	os.Setenv("APP_STORAGE_S3_BUCKET", "data")
+	os.Setenv("APP_STORAGE_S3_REGION", "eu-west-1")
+	os.Setenv("APP_PORT", "80")
+
+	var c cfg
+	a.NoError(l.Load(&c, "APP_"))
+	a.Equal(cfg{gcsStorage{"data"}}, c)
+	a.Equal([]string{
+		`"APP_STORAGE_GOOGLE_CLOUD_REGION" (env): unknown variable`,
+		`"APP_STORAGE_S3_BUCKET" (env): variant "s3" is not selected by APP_STORAGE_KIND`,
+		`"APP_STORAGE_S3_REGION" (env): variant "s3" is not selected by APP_STORAGE_KIND`,
+		`"APP_PORT" (env): unknown variable`,
+	}, warnings)
+
+	// Without the strict mode, there are no warnings.
+	warnings = nil
+	a.NoError(newStorageLoader(OnWarning(func(w Warning) {
+		warnings = append(warnings, w.String())
+	})).Load(&c, "APP_"))
+	a.Empty(warnings)
+}
+
+func TestVariantsUsage(t *testing.T) {
+	a := assert.New(t)
+
+	type cfg struct {
+		Storage storage `env:"STORAGE_" desc:"Storage backend."`
+	}
+
+	var buf bytes.Buffer
+	a.NoError(newStorageLoader().Usage(&buf, (*cfg)(nil), "APP_"))
+	a.Equal(`VARIABLE                         TYPE         DESCRIPTION
+APP_STORAGE_KIND                 env.storage  Storage backend. (one of: google-cloud, s3)
+APP_STORAGE_GOOGLE_CLOUD_BUCKET  string       (if APP_STORAGE_KIND=google-cloud)
+APP_STORAGE_S3_BUCKET            string       (if APP_STORAGE_KIND=s3)
+APP_STORAGE_S3_REGION            string       (if APP_STORAGE_KIND=s3)
+`, buf.String())
+}